@@ -0,0 +1,314 @@
+package multiglob
+
+import (
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// BatchOptions controls how the bulk methods, like Filter and Partition, spread their work.
+// The zero value matches on GOMAXPROCS goroutines and streams results in any order.
+type BatchOptions struct {
+	// Concurrency is how many goroutines match inputs at once. If it isn't positive,
+	// runtime.GOMAXPROCS(0) is used. With 1, the inputs are matched on the calling goroutine.
+	Concurrency int
+
+	// Ordered makes the streaming methods produce results in the order of their inputs.
+	// Otherwise a result comes as soon as it's ready, which keeps a slow input from holding up
+	// the ones after it. Filter and Partition always keep the order, since it's free for them.
+	Ordered bool
+}
+
+// PatternMatch is an input along with the name of the pattern that matches it, as FindPattern
+// returns it. See MultiGlob.PartitionChan.
+type PatternMatch struct {
+	Input string
+	Name  string
+}
+
+// batchSize is how many inputs are handed to a goroutine at once, so that the cost of
+// handing them over is small next to the cost of matching them.
+const batchSize = 256
+
+// Filter returns the inputs that a pattern matches, in order. Since a MultiGlob doesn't change
+// once it's compiled, the inputs are matched on several goroutines at once.
+func (mg *MultiGlob) Filter(inputs []string, opts BatchOptions) []string {
+	var found []string
+	opts.Ordered = true
+	mg.filter(sliceChunks(inputs), opts, func(input string) bool {
+		found = append(found, input)
+		return true
+	})
+	return found
+}
+
+// Partition groups the inputs that a pattern matches by the name of the pattern, as
+// FindPattern returns it, keeping their order within each group. The inputs that no pattern
+// matches are left out. Like Filter, it matches on several goroutines at once.
+func (mg *MultiGlob) Partition(inputs []string, opts BatchOptions) map[string][]string {
+	groups := make(map[string][]string)
+	opts.Ordered = true
+	mg.partition(sliceChunks(inputs), opts, func(name, input string) bool {
+		groups[name] = append(groups[name], input)
+		return true
+	})
+	return groups
+}
+
+// FilterSeq returns the inputs that a pattern matches, like Filter does, as they're matched.
+// Unless opts.Concurrency is 1, the inputs are read on a goroutine of their own, a few batches
+// ahead of the results. Once ranging stops, so does reading.
+func (mg *MultiGlob) FilterSeq(inputs iter.Seq[string], opts BatchOptions) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		mg.filter(seqChunks(inputs), opts, yield)
+	}
+}
+
+// PartitionSeq returns the name of the pattern that matches each input, along with the input,
+// like Partition does, as they're matched. See FilterSeq.
+func (mg *MultiGlob) PartitionSeq(inputs iter.Seq[string], opts BatchOptions) iter.Seq2[string, string] {
+	return func(yield func(name, input string) bool) {
+		mg.partition(seqChunks(inputs), opts, yield)
+	}
+}
+
+// FilterChan sends the inputs received from in that a pattern matches to the returned channel,
+// which is closed once in is and its inputs are all matched. Inputs are matched as soon as
+// they're received, without waiting for more to fill a batch. The returned channel has to be
+// drained, or the goroutines that match the inputs are leaked.
+func (mg *MultiGlob) FilterChan(in <-chan string, opts BatchOptions) <-chan string {
+	out := make(chan string, batchSize)
+	go func() {
+		defer close(out)
+		mg.filter(chanChunks(in), opts, func(input string) bool {
+			out <- input
+			return true
+		})
+	}()
+	return out
+}
+
+// PartitionChan sends the inputs received from in that a pattern matches to the returned
+// channel, along with the name of the pattern. See FilterChan.
+func (mg *MultiGlob) PartitionChan(in <-chan string, opts BatchOptions) <-chan PatternMatch {
+	out := make(chan PatternMatch, batchSize)
+	go func() {
+		defer close(out)
+		mg.partition(chanChunks(in), opts, func(name, input string) bool {
+			out <- PatternMatch{Input: input, Name: name}
+			return true
+		})
+	}()
+	return out
+}
+
+func (mg *MultiGlob) filter(src chunkSource, opts BatchOptions, yield func(string) bool) {
+	mg.batch(src, opts, func(c *batchChunk) bool {
+		for i, input := range c.inputs {
+			if c.matched[i] && !yield(input) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (mg *MultiGlob) partition(src chunkSource, opts BatchOptions, yield func(name, input string) bool) {
+	mg.batch(src, opts, func(c *batchChunk) bool {
+		for i, input := range c.inputs {
+			if c.matched[i] && !yield(c.names[i], input) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// batchChunk is a run of inputs that are matched together.
+type batchChunk struct {
+	seq     int // Position of the chunk among the others
+	inputs  []string
+	names   []string // The name of the pattern that matches each input
+	matched []bool   // Whether a pattern matches each input
+}
+
+func (mg *MultiGlob) matchChunk(c *batchChunk) {
+	c.names = make([]string, len(c.inputs))
+	c.matched = make([]bool, len(c.inputs))
+	for i, input := range c.inputs {
+		c.names[i], c.matched[i] = mg.FindPattern(input)
+	}
+}
+
+// chunkSource reads inputs in chunks for batch. next returns false once there are none left,
+// and stop, if it's set, is called once batch is done with the source, from the last
+// goroutine that called next.
+type chunkSource struct {
+	next func() ([]string, bool)
+	stop func()
+}
+
+func (src chunkSource) close() {
+	if src.stop != nil {
+		src.stop()
+	}
+}
+
+// batch matches the chunks read from src on opts.Concurrency goroutines, and calls emit with
+// each chunk once it's matched, on the calling goroutine, until emit returns false.
+func (mg *MultiGlob) batch(src chunkSource, opts BatchOptions, emit func(*batchChunk) bool) {
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	if workers == 1 {
+		defer src.close()
+		for inputs, ok := src.next(); ok; inputs, ok = src.next() {
+			c := &batchChunk{inputs: inputs}
+			mg.matchChunk(c)
+			if !emit(c) {
+				return
+			}
+		}
+		return
+	}
+
+	// Inputs are read on a goroutine of their own, so that results are emitted while it waits
+	// for more. A token is taken for each chunk read and given back once it's emitted, so at
+	// most window chunks are read and not emitted yet. The channels can hold all of them, so
+	// nothing blocks on a send, and the goroutines exit once emit stops early.
+	var (
+		window  = 2 * workers
+		tokens  = make(chan struct{}, window)
+		done    = make(chan struct{})
+		jobs    = make(chan *batchChunk, window)
+		results = make(chan *batchChunk, window)
+		wg      sync.WaitGroup
+	)
+	defer close(done)
+
+	go func() {
+		defer close(jobs)
+		defer src.close()
+		for seq := 0; ; seq++ {
+			select {
+			case <-done:
+				return
+			case tokens <- struct{}{}:
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			inputs, ok := src.next()
+			if !ok {
+				return
+			}
+			jobs <- &batchChunk{seq: seq, inputs: inputs}
+		}
+	}()
+
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				mg.matchChunk(c)
+				results <- c
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var (
+		emitted = 0 // How many chunks were emitted, in order
+		pending = make(map[int]*batchChunk)
+	)
+	for c := range results {
+		if !opts.Ordered {
+			<-tokens
+			if !emit(c) {
+				return
+			}
+			continue
+		}
+
+		// Hold on to the chunk until the ones before it are emitted
+		pending[c.seq] = c
+		for c := pending[emitted]; c != nil; c = pending[emitted] {
+			delete(pending, emitted)
+			emitted++
+			<-tokens
+			if !emit(c) {
+				return
+			}
+		}
+	}
+}
+
+// sliceChunks returns a source that splits the inputs into chunks.
+func sliceChunks(inputs []string) chunkSource {
+	return chunkSource{next: func() ([]string, bool) {
+		if len(inputs) == 0 {
+			return nil, false
+		}
+		n := min(batchSize, len(inputs))
+		chunk := inputs[:n:n]
+		inputs = inputs[n:]
+		return chunk, true
+	}}
+}
+
+// seqChunks returns a source that reads the inputs in chunks of batchSize.
+func seqChunks(inputs iter.Seq[string]) chunkSource {
+	pull, stop := iter.Pull(inputs)
+	return chunkSource{
+		next: func() ([]string, bool) {
+			var chunk []string
+			for len(chunk) < batchSize {
+				input, ok := pull()
+				if !ok {
+					break
+				}
+				if chunk == nil {
+					chunk = make([]string, 0, batchSize)
+				}
+				chunk = append(chunk, input)
+			}
+			return chunk, len(chunk) > 0
+		},
+		stop: stop,
+	}
+}
+
+// chanChunks returns a source that reads the values received from a channel in chunks. A
+// chunk holds the values that were received without waiting, after the first one, up to
+// batchSize of them.
+func chanChunks(ch <-chan string) chunkSource {
+	return chunkSource{next: func() ([]string, bool) {
+		input, ok := <-ch
+		if !ok {
+			return nil, false
+		}
+
+		chunk := append(make([]string, 0, batchSize), input)
+		for len(chunk) < batchSize {
+			select {
+			case input, ok := <-ch:
+				if !ok {
+					return chunk, true
+				}
+				chunk = append(chunk, input)
+			default:
+				return chunk, true
+			}
+		}
+		return chunk, true
+	}}
+}
@@ -0,0 +1,173 @@
+package multiglob
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+// batchTestInputs returns enough inputs to fill several batches, along with the ones that
+// end in an even digit or in 7, grouped under "even" and "seven".
+func batchTestInputs() ([]string, []string, map[string][]string) {
+	var (
+		inputs  []string
+		matched []string
+		groups  = make(map[string][]string)
+	)
+	for i := 0; i < 5*batchSize+3; i++ {
+		input := fmt.Sprint("key-", i)
+		inputs = append(inputs, input)
+
+		switch i % 10 {
+		case 0, 2, 4, 6, 8:
+			groups["even"] = append(groups["even"], input)
+		case 7:
+			groups["seven"] = append(groups["seven"], input)
+		default:
+			continue
+		}
+		matched = append(matched, input)
+	}
+	return inputs, matched, groups
+}
+
+func TestFilterPartition(t *testing.T) {
+	b := New()
+	b.MustAddPattern("even", "*[02468]")
+	b.MustAddPattern("seven", "*7")
+	mg := b.MustCompile()
+	inputs, matched, groups := batchTestInputs()
+
+	for _, concurrency := range []int{0, 1, 3} {
+		t.Run(fmt.Sprint(concurrency), func(t *testing.T) {
+			require := r.New(t)
+			opts := BatchOptions{Concurrency: concurrency}
+
+			require.Equal(matched, mg.Filter(inputs, opts))
+			require.Equal(groups, mg.Partition(inputs, opts))
+
+			require.Empty(mg.Filter(nil, opts))
+			require.Empty(mg.Partition([]string{"nothing"}, opts))
+		})
+	}
+}
+
+func TestFilterSeq(t *testing.T) {
+	b := New()
+	b.MustAddPattern("even", "*[02468]")
+	b.MustAddPattern("seven", "*7")
+	mg := b.MustCompile()
+	inputs, matched, groups := batchTestInputs()
+
+	for _, concurrency := range []int{0, 1, 3} {
+		for _, ordered := range []bool{false, true} {
+			t.Run(fmt.Sprint(concurrency, ordered), func(t *testing.T) {
+				require := r.New(t)
+				opts := BatchOptions{Concurrency: concurrency, Ordered: ordered}
+
+				found := slices.Collect(mg.FilterSeq(slices.Values(inputs), opts))
+				if !ordered {
+					sort.Slice(found, func(i, j int) bool {
+						return slices.Index(inputs, found[i]) < slices.Index(inputs, found[j])
+					})
+				}
+				require.Equal(matched, found)
+
+				partitioned := make(map[string][]string)
+				for name, input := range mg.PartitionSeq(slices.Values(inputs), opts) {
+					partitioned[name] = append(partitioned[name], input)
+				}
+				if !ordered {
+					for _, group := range partitioned {
+						sort.Slice(group, func(i, j int) bool {
+							return slices.Index(inputs, group[i]) < slices.Index(inputs, group[j])
+						})
+					}
+				}
+				require.Equal(groups, partitioned)
+			})
+		}
+	}
+}
+
+func TestFilterSeqStopsEarly(t *testing.T) {
+	b := New()
+	b.MustAddPattern("even", "*[02468]")
+	b.MustAddPattern("seven", "*7")
+	mg := b.MustCompile()
+	inputs, matched, _ := batchTestInputs()
+
+	for _, concurrency := range []int{1, 3} {
+		t.Run(fmt.Sprint(concurrency), func(t *testing.T) {
+			require := r.New(t)
+
+			var found []string
+			for input := range mg.FilterSeq(slices.Values(inputs), BatchOptions{Concurrency: concurrency, Ordered: true}) {
+				found = append(found, input)
+				if len(found) == 3 {
+					break
+				}
+			}
+			require.Equal(matched[:3], found)
+		})
+	}
+}
+
+func TestFilterChan(t *testing.T) {
+	require := r.New(t)
+	b := New()
+	b.MustAddPattern("even", "*[02468]")
+	b.MustAddPattern("seven", "*7")
+	mg := b.MustCompile()
+	inputs, matched, groups := batchTestInputs()
+
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for _, input := range inputs {
+			in <- input
+		}
+	}()
+
+	var found []string
+	for input := range mg.FilterChan(in, BatchOptions{Ordered: true}) {
+		found = append(found, input)
+	}
+	require.Equal(matched, found)
+
+	in = make(chan string, len(inputs))
+	for _, input := range inputs {
+		in <- input
+	}
+	close(in)
+
+	partitioned := make(map[string][]string)
+	for m := range mg.PartitionChan(in, BatchOptions{Concurrency: 2, Ordered: true}) {
+		partitioned[m.Name] = append(partitioned[m.Name], m.Input)
+	}
+	require.Equal(groups, partitioned)
+}
+
+// TestFilterChanResponds checks that an input is matched without waiting for the ones after
+// it, so that a sender that waits for each result doesn't deadlock.
+func TestFilterChanResponds(t *testing.T) {
+	require := r.New(t)
+	b := New()
+	b.MustAddPattern("even", "*[02468]")
+	b.MustAddPattern("seven", "*7")
+	mg := b.MustCompile()
+
+	in := make(chan string)
+	out := mg.FilterChan(in, BatchOptions{Concurrency: 4})
+	for _, input := range []string{"a0", "b7", "c2"} {
+		in <- input
+		require.Equal(input, <-out)
+	}
+	close(in)
+
+	_, ok := <-out
+	require.False(ok)
+}
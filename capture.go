@@ -0,0 +1,270 @@
+package multiglob
+
+import (
+	"encoding/binary"
+	"iter"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// CaptureStrategy decides which globs are extracted from an input that a pattern matches in
+// more than one way, like "pen pineapple apple pen" with "*apple*".
+type CaptureStrategy int
+
+const (
+	// CaptureGreedy makes each * and repeated range, from left to right, match as much of the
+	// input as it can: "*apple*" binds the last "apple", and returns
+	// ["pen pineapple ", " pen"]. The exception is a * right before a range, which leaves the
+	// range the whole run of characters it matches: "*[d-f]+" returns ["abc", "def"] for
+	// "abcdef".
+	CaptureGreedy CaptureStrategy = iota
+	// CaptureLazy makes each * and repeated range, from left to right, match as little of the
+	// input as it can: "*apple*" binds the first "apple", and returns
+	// ["pen pine", " apple pen"].
+	CaptureLazy
+)
+
+// FindGlobsWith is like FindGlobs, but extracts the globs with the given strategy.
+func (mg *MultiGlob) FindGlobsWith(input string, strategy CaptureStrategy) (name string, globs []string, matched bool) {
+	name, ok := mg.FindPattern(input)
+	if !ok {
+		return "", nil, false
+	}
+
+	globs, _, _ = extractGlobsForName(input, mg.patterns[name], strategy)
+	return name, globs, true
+}
+
+// FindGlobSpansWith is like FindGlobSpans, but picks the spans with the given strategy.
+func (mg *MultiGlob) FindGlobSpansWith(input string, strategy CaptureStrategy) (name string, spans []Span, matched bool) {
+	name, ok := mg.FindPattern(input)
+	if !ok {
+		return "", nil, false
+	}
+
+	spans, _, _ = extractSpansForName(input, mg.patterns[name], strategy)
+	return name, spans, true
+}
+
+// FindGlobsForPatternWith is like FindGlobsForPattern, but extracts the globs with the given
+// strategy.
+func (mg *MultiGlob) FindGlobsForPatternWith(input, name string, strategy CaptureStrategy) (globs []string, err error) {
+	asts, ok := mg.patterns[name]
+	if !ok {
+		return nil, errors.New("pattern not found")
+	}
+
+	globs, _, err = extractGlobsForName(input, asts, strategy)
+	if err != nil {
+		return nil, errors.New("pattern did not match input")
+	}
+	return globs, nil
+}
+
+// Decompositions returns every distinct way the patterns stored under name match the input,
+// as spans like FindGlobSpans returns. They come in greedy order, so the first one is what
+// FindGlobSpans returns. An input can match in exponentially many ways, so at most limit are
+// returned, and none if limit isn't positive.
+func (mg *MultiGlob) Decompositions(input, name string, limit int) iter.Seq[[]Span] {
+	asts := mg.patterns[name]
+
+	return func(yield func([]Span) bool) {
+		if limit <= 0 {
+			return
+		}
+
+		// The patterns of a name can match the same way, when they're only written differently
+		seen := make(map[string]bool)
+		count := 0
+		for _, ast := range asts {
+			more := decompose(input, 0, ast, false, nil, func(spans []Span) bool {
+				key := spansKey(spans)
+				if seen[key] {
+					return true
+				}
+				seen[key] = true
+
+				count++
+				return yield(slices.Clone(spans)) && count < limit
+			})
+			if !more {
+				return
+			}
+		}
+	}
+}
+
+func spansKey(spans []Span) string {
+	b := make([]byte, 0, len(spans)*(2*binary.MaxVarintLen64+1))
+	for _, s := range spans {
+		b = binary.AppendUvarint(b, uint64(s.Start))
+		b = binary.AppendUvarint(b, uint64(s.End))
+		if s.Literal {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+	}
+	return string(b)
+}
+
+// decompose calls yield with each way the tree rooted at ast matches the input, as the spans
+// of its parts, until yield returns false. offset is the offset of input in the full input,
+// and spans holds the spans of the parts before ast. The spans passed to yield are only valid
+// during the call. Each * and repeated range tries the longest match first, or the shortest if
+// lazy is set. It returns false if yield did.
+func decompose(input string, offset int, ast *parser.Node, lazy bool, spans []Span, yield func([]Span) bool) bool {
+	// next matches what's left of the input after ast matched the first end bytes of it
+	next := func(end int, spans []Span) bool {
+		rest := input[end:]
+		if ast.Leaf && rest == "" && !yield(spans) {
+			return false
+		}
+		for _, child := range ast.Children {
+			if !decompose(rest, offset+end, child, lazy, spans, yield) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch ast.Type {
+	case parser.TypeRoot:
+		return next(0, spans)
+	case parser.TypeText:
+		if !strings.HasPrefix(input, ast.Value) {
+			return true
+		}
+		return next(len(ast.Value), append(spans, Span{Start: offset, End: offset + len(ast.Value), Literal: true}))
+	case parser.TypeAny:
+		try := func(end int) bool {
+			return next(end, append(spans, Span{Start: offset, End: offset + end}))
+		}
+		if len(ast.Children) == 0 {
+			return try(len(input))
+		}
+		return eachEnd(input, ast, lazy, try)
+	case parser.TypeRange:
+		runEnd := 0
+		for runEnd < len(input) {
+			r, size := utf8.DecodeRuneInString(input[runEnd:])
+			if !ast.Range.Matches(r) {
+				break
+			}
+			runEnd += size
+			if !ast.Range.Repeated {
+				break
+			}
+		}
+
+		for end := range runEnds(input[:runEnd], lazy) {
+			if !next(end, append(spans, Span{Start: offset, End: offset + end})) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// eachEnd calls try with each offset of the input that the * node ast could stop matching at,
+// from the last to the first, or the other way around if lazy is set, until try returns
+// false. It returns false if try did.
+func eachEnd(input string, ast *parser.Node, lazy bool, try func(end int) bool) bool {
+	// Where the only thing after the * is text, skip straight to where it's found
+	if !ast.Leaf && len(ast.Children) == 1 && ast.Children[0].Type == parser.TypeText && ast.Children[0].Value != "" {
+		text := ast.Children[0].Value
+		if lazy {
+			for offset := 0; offset <= len(input); {
+				i := strings.Index(input[offset:], text)
+				if i < 0 {
+					return true
+				}
+				start := offset + i
+				if !try(start) {
+					return false
+				}
+
+				_, size := utf8.DecodeRuneInString(input[start:])
+				offset = start + max(size, 1)
+			}
+			return true
+		}
+
+		for end := len(input); end >= 0; {
+			i := strings.LastIndex(input[:end], text)
+			if i < 0 {
+				return true
+			}
+			if !try(i) {
+				return false
+			}
+			// Look for matches that start before this one, even if they overlap it
+			end = i + len(text) - 1
+		}
+		return true
+	}
+
+	if !lazy && !ast.Leaf && len(ast.Children) == 1 && ast.Children[0].Type == parser.TypeRange {
+		// A greedy * before a range stops where a run of the range starts first, so that the
+		// range gets the whole run: "*[d-f]+" splits "abcdef" into "abc" and "def"
+		r := ast.Children[0].Range
+		runStart := func(end int) bool {
+			next, _ := utf8.DecodeRuneInString(input[end:])
+			prev, size := utf8.DecodeLastRuneInString(input[:end])
+			return end < len(input) && r.Matches(next) && (size == 0 || !r.Matches(prev))
+		}
+
+		for _, first := range []bool{true, false} {
+			for end := range runEnds(input, false) {
+				if runStart(end) == first && !try(end) {
+					return false
+				}
+			}
+			if runStart(0) == first && !try(0) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Unlike a range, a * can match nothing at all
+	if lazy && !try(0) {
+		return false
+	}
+	for end := range runEnds(input, lazy) {
+		if !try(end) {
+			return false
+		}
+	}
+	return lazy || try(0)
+}
+
+// runEnds returns the offsets of the input between runes, including its end but not its start,
+// from the last to the first, or the other way around if lazy is set.
+func runEnds(input string, lazy bool) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if lazy {
+			for end := 0; end < len(input); {
+				_, size := utf8.DecodeRuneInString(input[end:])
+				end += size
+				if !yield(end) {
+					return
+				}
+			}
+			return
+		}
+
+		for end := len(input); end > 0; {
+			if !yield(end) {
+				return
+			}
+			_, size := utf8.DecodeLastRuneInString(input[:end])
+			end -= size
+		}
+	}
+}
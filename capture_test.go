@@ -0,0 +1,244 @@
+package multiglob
+
+import (
+	"slices"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestFindGlobsWith(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		greedy  []string
+		lazy    []string
+	}{
+		{
+			input:   "pen pineapple apple pen",
+			pattern: "*apple*",
+			greedy:  []string{"pen pineapple ", " pen"},
+			lazy:    []string{"pen pine", " apple pen"},
+		},
+		{
+			input:   "aaa",
+			pattern: "*a*",
+			greedy:  []string{"aa", ""},
+			lazy:    []string{"", "aa"},
+		},
+		{
+			input:   "xabc",
+			pattern: "x[a-z]+*",
+			greedy:  []string{"abc", ""},
+			lazy:    []string{"a", "bc"},
+		},
+		{
+			input:   "abcdef",
+			pattern: "*[d-f]+",
+			greedy:  []string{"abc", "def"},
+			lazy:    []string{"abc", "def"},
+		},
+		{
+			input:   "a-b-c",
+			pattern: "*-*",
+			greedy:  []string{"a-b", "c"},
+			lazy:    []string{"a", "b-c"},
+		},
+		{
+			input:   "test",
+			pattern: "test",
+			greedy:  nil,
+			lazy:    nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			b.MustAddPattern("p", test.pattern)
+			mg := b.MustCompile()
+
+			_, globs, matched := mg.FindGlobsWith(test.input, CaptureGreedy)
+			require.True(matched)
+			require.Equal(test.greedy, globs)
+
+			_, globs, matched = mg.FindGlobsWith(test.input, CaptureLazy)
+			require.True(matched)
+			require.Equal(test.lazy, globs)
+
+			globs, err := mg.FindGlobsForPatternWith(test.input, "p", CaptureLazy)
+			require.NoError(err)
+			require.Equal(test.lazy, globs)
+
+			_, spans, matched := mg.FindGlobSpansWith(test.input, CaptureLazy)
+			require.True(matched)
+			var fromSpans []string
+			for _, s := range spans {
+				if !s.Literal {
+					fromSpans = append(fromSpans, test.input[s.Start:s.End])
+				}
+			}
+			require.Equal(test.lazy, fromSpans)
+		})
+	}
+}
+
+func TestFindGlobsForPatternWithErrors(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("p", "*apple")
+	mg := b.MustCompile()
+
+	_, err := mg.FindGlobsForPatternWith("pear", "p", CaptureLazy)
+	require.Error(err)
+	_, err = mg.FindGlobsForPatternWith("apple", "missing", CaptureLazy)
+	require.Error(err)
+}
+
+func TestDecompositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		input    string
+		limit    int
+		output   [][]string
+	}{
+		{
+			name:     "every split",
+			patterns: []string{"*a*"},
+			input:    "aaa",
+			limit:    10,
+			output: [][]string{
+				{"aa", ""},
+				{"a", "a"},
+				{"", "aa"},
+			},
+		},
+		{
+			name:     "capped",
+			patterns: []string{"*a*"},
+			input:    "aaa",
+			limit:    2,
+			output: [][]string{
+				{"aa", ""},
+				{"a", "a"},
+			},
+		},
+		{
+			name:     "no limit",
+			patterns: []string{"*a*"},
+			input:    "aaa",
+			limit:    0,
+			output:   nil,
+		},
+		{
+			name:     "no match",
+			patterns: []string{"*b*"},
+			input:    "aaa",
+			limit:    10,
+			output:   nil,
+		},
+		{
+			name:     "unambiguous",
+			patterns: []string{"*.tar.gz"},
+			input:    "backup.tar.gz",
+			limit:    10,
+			output: [][]string{
+				{"backup"},
+			},
+		},
+		{
+			name:     "ambiguous file name",
+			patterns: []string{"*.*"},
+			input:    "backup.tar.gz",
+			limit:    10,
+			output: [][]string{
+				{"backup.tar", "gz"},
+				{"backup", "tar.gz"},
+			},
+		},
+		{
+			name:     "range after a star",
+			patterns: []string{"*[0-9]+"},
+			input:    "v12",
+			limit:    10,
+			output: [][]string{
+				{"v", "12"},
+				{"v1", "2"},
+			},
+		},
+		{
+			name:     "duplicates",
+			patterns: []string{"*a*", "*a*", "*[a]*"},
+			input:    "aa",
+			limit:    10,
+			output: [][]string{
+				{"a", ""},
+				{"", "a"},
+				// The range is a glob of its own, so these are different
+				{"", "a", "a"},
+				{"a", "a", ""},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			b.SetDuplicatePolicy(DuplicateKeepAll)
+			for _, p := range test.patterns {
+				b.MustAddPattern("p", p)
+			}
+			mg := b.MustCompile()
+
+			var output [][]string
+			for spans := range mg.Decompositions(test.input, "p", test.limit) {
+				// Every decomposition covers the whole input, in order
+				end := 0
+				var globs []string
+				for _, s := range spans {
+					require.Equal(end, s.Start)
+					end = s.End
+					if !s.Literal {
+						globs = append(globs, test.input[s.Start:s.End])
+					}
+				}
+				require.Equal(len(test.input), end)
+
+				output = append(output, globs)
+			}
+			require.Equal(test.output, output)
+
+			if len(test.output) > 0 && test.limit > 0 {
+				_, spans, matched := mg.FindGlobSpans(test.input)
+				require.True(matched)
+				first := slices.Collect(mg.Decompositions(test.input, "p", 1))
+				require.Equal([][]Span{spans}, first)
+			}
+		})
+	}
+}
+
+func TestDecompositionsStop(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("p", "*a*a*")
+	mg := b.MustCompile()
+
+	count := 0
+	for range mg.Decompositions("aaaaaaaa", "p", 100) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	require.Equal(3, count)
+
+	require.Empty(slices.Collect(mg.Decompositions("aaa", "missing", 10)))
+}
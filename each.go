@@ -0,0 +1,106 @@
+package multiglob
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+// EachPattern calls fn with the name of each pattern that matches the input, in the order
+// FindAllPatterns returns them, until fn returns false. Each name is passed once. Unlike
+// FindAllPatterns, it doesn't allocate once it has been called a few times.
+func (mg *MultiGlob) EachPattern(input string, fn func(name string) bool) {
+	seen := seenPool.Get().(*nameSet)
+	defer func() {
+		seen.reset()
+		seenPool.Put(seen)
+	}()
+
+	visit(mg.node, input, nil, func(names []string) bool {
+		for _, name := range names {
+			if seen.add(name) && !fn(name) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// AllPatterns returns the names of the patterns that match the input. See EachPattern.
+func (mg *MultiGlob) AllPatterns(input string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		mg.EachPattern(input, yield)
+	}
+}
+
+// AppendPatterns appends the names of the patterns that match the input to dst, in the order
+// FindAllPatterns returns them, and returns the extended slice. Names that are already in dst
+// are still appended once. It only allocates if dst has to grow.
+func (mg *MultiGlob) AppendPatterns(dst []string, input string) []string {
+	seen := seenPool.Get().(*nameSet)
+	defer func() {
+		seen.reset()
+		seenPool.Put(seen)
+	}()
+
+	visit(mg.node, input, nil, func(names []string) bool {
+		for _, name := range names {
+			if seen.add(name) {
+				dst = append(dst, name)
+			}
+		}
+		return true
+	})
+	return dst
+}
+
+// seenPool holds the sets that EachPattern and AppendPatterns keep the names they passed in.
+var seenPool = sync.Pool{
+	New: func() any {
+		return new(nameSet)
+	},
+}
+
+// listedNames is the number of names a nameSet looks up in a list before moving them to a map.
+const listedNames = 16
+
+// nameSet is a set of pattern names. Few patterns match any one input, so the first names are
+// looked up in a list, which is quicker than hashing them. Past that, they're kept in a map so
+// that inputs matching many patterns don't take quadratic time.
+type nameSet struct {
+	list []string
+	set  map[string]bool
+}
+
+// add adds the name to the set, and returns false if it was already in it.
+func (s *nameSet) add(name string) bool {
+	if len(s.list) < listedNames {
+		if slices.Contains(s.list, name) {
+			return false
+		}
+		s.list = append(s.list, name)
+		return true
+	}
+
+	if len(s.set) == 0 {
+		if s.set == nil {
+			s.set = make(map[string]bool)
+		}
+		for _, n := range s.list {
+			s.set[n] = true
+		}
+	}
+
+	if s.set[name] {
+		return false
+	}
+	s.set[name] = true
+	return true
+}
+
+// reset empties the set, keeping the memory it holds for the next use.
+func (s *nameSet) reset() {
+	clear(s.list)
+	s.list = s.list[:0]
+	clear(s.set)
+}
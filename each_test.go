@@ -0,0 +1,149 @@
+package multiglob
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestEachPattern(t *testing.T) {
+	// foo has two patterns that can both match, but is only reported once
+	b := New()
+	b.SetDuplicatePolicy(DuplicateKeepAll)
+	b.MustAddPattern("foo", "foo*")
+	b.MustAddPattern("foo", "*bar")
+	b.MustAddPattern("any", "*")
+	b.MustAddPattern("as", "*a*")
+	b.MustAddPattern("digits", "[0-9]+*")
+	mg := b.MustCompile()
+
+	for _, input := range []string{"foobar", "foo", "bar", "banana", "42abc", "", "zzz"} {
+		t.Run(input, func(t *testing.T) {
+			require := r.New(t)
+			expected := mg.FindAllPatterns(input)
+
+			var found []string
+			mg.EachPattern(input, func(name string) bool {
+				found = append(found, name)
+				return true
+			})
+			require.Equal(expected, found)
+
+			require.Equal(found, slices.Collect(mg.AllPatterns(input)))
+			require.Equal(found, mg.AppendPatterns(nil, input))
+		})
+	}
+}
+
+func TestEachPatternStops(t *testing.T) {
+	require := r.New(t)
+	b := New()
+	b.MustAddPattern("foo", "foo*")
+	b.MustAddPattern("any", "*")
+	b.MustAddPattern("as", "*a*")
+	b.MustAddPattern("digits", "[0-9]+*")
+	mg := b.MustCompile()
+
+	var found []string
+	mg.EachPattern("foobar", func(name string) bool {
+		found = append(found, name)
+		return len(found) < 2
+	})
+	require.Len(found, 2)
+
+	for name := range mg.AllPatterns("foobar") {
+		require.Equal(found[0], name)
+		break
+	}
+}
+
+func TestAppendPatterns(t *testing.T) {
+	require := r.New(t)
+	b := New()
+	b.MustAddPattern("foo", "foo*")
+	b.MustAddPattern("any", "*")
+	b.MustAddPattern("as", "*a*")
+	b.MustAddPattern("digits", "[0-9]+*")
+	mg := b.MustCompile()
+
+	// Names already in dst are kept, and appended again if they match
+	dst := mg.AppendPatterns([]string{"foo", "other"}, "foobar")
+	require.Equal("other", dst[1])
+	require.Equal(append([]string{"foo", "other"}, mg.FindAllPatterns("foobar")...), dst)
+
+	// Nothing is appended to a nil dst when nothing matches
+	b = New()
+	b.MustAddPattern("foo", "foo*")
+	require.Nil(b.MustCompile().AppendPatterns(nil, "zzz"))
+}
+
+func TestEachPatternAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations can't be counted under the race detector")
+	}
+	require := r.New(t)
+	b := New()
+	b.MustAddPattern("foo", "foo*")
+	b.MustAddPattern("any", "*")
+	b.MustAddPattern("as", "*a*")
+	b.MustAddPattern("digits", "[0-9]+*")
+	mg := b.MustCompile()
+
+	count := 0
+	count1 := func(string) bool {
+		count++
+		return true
+	}
+	dst := make([]string, 0, 16)
+
+	require.Zero(testing.AllocsPerRun(100, func() {
+		mg.EachPattern("foobanana", count1)
+	}))
+	require.Zero(testing.AllocsPerRun(100, func() {
+		for range mg.AllPatterns("foobanana") {
+			count++
+		}
+	}))
+	require.Zero(testing.AllocsPerRun(100, func() {
+		dst = mg.AppendPatterns(dst[:0], "foobanana")
+	}))
+	require.NotZero(count)
+	require.Len(dst, 3)
+}
+
+func TestEachPatternManyMatches(t *testing.T) {
+	require := r.New(t)
+
+	// Every name has two patterns that match, and there are more names than fit in the list
+	// of a nameSet
+	b := New()
+	b.SetDuplicatePolicy(DuplicateKeepAll)
+	for i := 0; i < 5*listedNames; i++ {
+		b.MustAddPattern(fmt.Sprint("p", i), "*")
+		b.MustAddPattern(fmt.Sprint("p", i), "x*")
+	}
+	mg := b.MustCompile()
+
+	expected := mg.FindAllPatterns("xyz")
+	require.Len(expected, 5*listedNames)
+	require.Equal(expected, slices.Collect(mg.AllPatterns("xyz")))
+	require.Equal(expected, mg.AppendPatterns(nil, "xyz"))
+	require.Equal(expected, mg.AppendPatterns(nil, "xyz"))
+}
+
+func BenchmarkAppendPatternsManyMatches(b *testing.B) {
+	builder := New()
+	for i := 0; i < 5000; i++ {
+		builder.MustAddPattern(fmt.Sprint(i), "*")
+	}
+	mg := builder.MustCompile()
+	dst := make([]string, 0, 5000)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst = mg.AppendPatterns(dst[:0], "input")
+	}
+}
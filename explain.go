@@ -0,0 +1,221 @@
+package multiglob
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// Explanation describes how a MultiGlob matched an input, as returned by Explain. It encodes
+// to JSON as is.
+type Explanation struct {
+	Input    string        `json:"input"`
+	Matched  bool          `json:"matched"`
+	Steps    []ExplainStep `json:"steps"`    // The walk through the merged tree, in order
+	Verdicts []Verdict     `json:"verdicts"` // One for each pattern name, sorted by name
+}
+
+// ExplainStep is a visit of a node of the merged tree, at an offset of the input.
+type ExplainStep struct {
+	Depth   int      `json:"depth"`             // Depth of the node in the tree, the root being 0
+	Type    string   `json:"type"`              // Type of the node, as in TreeNode
+	Node    string   `json:"node"`              // The part of a pattern the node matches
+	Offset  int      `json:"offset"`            // Byte offset of the input the node was tried at
+	Matched []string `json:"matched,omitempty"` // Names of the patterns matched through the node
+	Reason  string   `json:"reason,omitempty"`  // Why nothing matched through the node
+}
+
+// Verdict says whether the patterns of one name matched an input, and if not, how far the
+// closest one got.
+type Verdict struct {
+	Name    string `json:"name"`
+	Matched bool   `json:"matched"`
+	Pattern string `json:"pattern"` // The pattern that matched, or got furthest
+
+	// For patterns that didn't match, the part of the pattern that failed, the offset of the
+	// input it failed at, and why.
+	Node   string `json:"node,omitempty"`
+	Offset int    `json:"offset"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (v Verdict) String() string {
+	if v.Matched {
+		return fmt.Sprintf("%s: matched by %s", v.Name, v.Pattern)
+	}
+	return fmt.Sprintf("%s: no match for %s: %s at offset %d: %s", v.Name, v.Pattern, v.Node, v.Offset, v.Reason)
+}
+
+// Explain matches the input against every pattern and records how it went: the walk through
+// the merged tree, and for each pattern name, whether it matched and where it failed if it
+// didn't. It's much slower than matching, and meant for debugging.
+func (mg *MultiGlob) Explain(input string) *Explanation {
+	t := &tracer{input: input}
+	_, matched := trace(mg.node, input, true, t)
+
+	e := &Explanation{
+		Input:   input,
+		Matched: matched,
+		Steps:   t.steps,
+	}
+
+	for _, name := range mg.Names() {
+		e.Verdicts = append(e.Verdicts, explainName(name, mg.patterns[name], input))
+	}
+	return e
+}
+
+// explainName works out the verdict for the patterns of a name by walking each on its own.
+func explainName(name string, asts []*parser.Node, input string) Verdict {
+	var best Verdict
+	for i, ast := range asts {
+		t := &tracer{input: input}
+		if _, ok := trace(ast, input, false, t); ok {
+			return Verdict{
+				Name:    name,
+				Matched: true,
+				Pattern: ast.String(),
+			}
+		}
+
+		// The deepest failure is the one that got furthest through the pattern
+		v := Verdict{
+			Name:    name,
+			Pattern: ast.String(),
+			Offset:  -1,
+		}
+		depth := -1
+		for _, step := range t.steps {
+			if step.Reason != "" && (step.Depth > depth || step.Depth == depth && step.Offset > v.Offset) {
+				depth = step.Depth
+				v.Node, v.Offset, v.Reason = step.Node, step.Offset, step.Reason
+			}
+		}
+
+		if i == 0 || v.Offset > best.Offset {
+			best = v
+		}
+	}
+	return best
+}
+
+func (e *Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "input %q: ", e.Input)
+	if e.Matched {
+		b.WriteString("matched\n")
+	} else {
+		b.WriteString("no match\n")
+	}
+
+	for _, step := range e.Steps {
+		b.WriteString(strings.Repeat("  ", step.Depth+1))
+		node := step.Node
+		if node == "" {
+			node = step.Type
+		}
+
+		fmt.Fprintf(&b, "%s at %d: ", node, step.Offset)
+		if len(step.Matched) > 0 {
+			fmt.Fprintf(&b, "matched %s\n", strings.Join(step.Matched, ", "))
+		} else {
+			fmt.Fprintf(&b, "%s\n", step.Reason)
+		}
+	}
+
+	for _, v := range e.Verdicts {
+		fmt.Fprintf(&b, "%s\n", v)
+	}
+	return b.String()
+}
+
+// tracer records the walk of trace.
+type tracer struct {
+	input string
+	depth int
+	steps []ExplainStep
+}
+
+// enter records the visit of a node, and returns the index of its step.
+func (t *tracer) enter(node *parser.Node, input string) int {
+	t.steps = append(t.steps, ExplainStep{
+		Depth:  t.depth,
+		Type:   nodeTypeName(node.Type),
+		Node:   node.Pattern(),
+		Offset: len(t.input) - len(input),
+	})
+	t.depth++
+	return len(t.steps) - 1
+}
+
+// exit records the outcome of the visit of a node.
+func (t *tracer) exit(step int, node *parser.Node, input string, results []string) {
+	t.depth--
+
+	if len(results) > 0 {
+		matched := append([]string(nil), results...)
+		sort.Strings(matched)
+		t.steps[step].Matched = matched
+		return
+	}
+
+	t.steps[step].Reason = failureReason(node, input, t.steps[step+1:])
+}
+
+// failureReason describes why nothing matched the input through the node. steps are the
+// steps taken below the node.
+func failureReason(node *parser.Node, input string, steps []ExplainStep) string {
+	switch node.Type {
+	case parser.TypeAny:
+		if len(steps) == 0 && len(node.Children) == 1 && node.Children[0].Type == parser.TypeText {
+			return fmt.Sprintf("%q not found in %q", node.Children[0].Value, input)
+		}
+		return "nothing after it matched"
+	case parser.TypeText:
+		if !strings.HasPrefix(input, node.Value) {
+			if strings.HasPrefix(node.Value, input) {
+				return fmt.Sprintf("input ended, expected %q", node.Value[len(input):])
+			}
+			return fmt.Sprintf("expected %q, found %q", node.Value, runePrefix(input, utf8.RuneCountInString(node.Value)))
+		}
+		input = input[len(node.Value):]
+	case parser.TypeRange:
+		r, size := utf8.DecodeRuneInString(input)
+		if size == 0 {
+			return fmt.Sprintf("input ended, expected %s", node.Range)
+		}
+		if !node.Range.Matches(r) {
+			return fmt.Sprintf("%q doesn't match %s", r, node.Range)
+		}
+
+		input = input[size:]
+		for node.Range.Repeated {
+			if r, size = utf8.DecodeRuneInString(input); size == 0 || !node.Range.Matches(r) {
+				break
+			}
+			input = input[size:]
+		}
+	}
+
+	if len(node.Children) == 0 {
+		return fmt.Sprintf("pattern ended, but %q is left over", input)
+	}
+	if len(steps) == 0 {
+		return "no way to continue"
+	}
+	return "nothing matched after this"
+}
+
+// runePrefix returns the first n runes of s.
+func runePrefix(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
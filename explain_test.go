@@ -0,0 +1,97 @@
+package multiglob
+
+import (
+	"encoding/json"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("logs", "logs/*.log")
+	b.MustAddPattern("errors", "*.err")
+	b.MustAddPattern("version", "v[0-9]+")
+
+	e := b.MustCompile().Explain("logs/app.txt")
+	require.False(e.Matched)
+	require.Equal([]Verdict{
+		{
+			Name:    "errors",
+			Pattern: "*.err",
+			Node:    "*",
+			Offset:  0,
+			Reason:  `".err" not found in "logs/app.txt"`,
+		},
+		{
+			Name:    "logs",
+			Pattern: "logs/*.log",
+			Node:    "*",
+			Offset:  5,
+			Reason:  `".log" not found in "app.txt"`,
+		},
+		{
+			Name:    "version",
+			Pattern: "v[0-9]+",
+			Node:    "v",
+			Offset:  0,
+			Reason:  `expected "v", found "l"`,
+		},
+	}, e.Verdicts)
+
+	require.Equal(ExplainStep{Depth: 0, Type: "root", Offset: 0, Reason: "nothing matched after this"}, e.Steps[0])
+	require.Contains(e.Steps, ExplainStep{Depth: 1, Type: "text", Node: "logs/", Offset: 0, Reason: "nothing matched after this"})
+	require.Contains(e.Steps, ExplainStep{Depth: 2, Type: "any", Node: "*", Offset: 5, Reason: `".log" not found in "app.txt"`})
+}
+
+func TestExplainMatch(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("logs", "logs/*.log")
+	b.MustAddPattern("errors", "*.err")
+	b.MustAddPattern("version", "v[0-9]+")
+	mg := b.MustCompile()
+
+	e := mg.Explain("v12")
+	require.True(e.Matched)
+	require.Equal(Verdict{Name: "version", Matched: true, Pattern: "v[0-9]+"}, e.Verdicts[2])
+	require.Equal("version: matched by v[0-9]+", e.Verdicts[2].String())
+	require.Contains(e.Steps, ExplainStep{Depth: 2, Type: "range", Node: "[0-9]+", Offset: 1, Matched: []string{"version"}})
+
+	e = mg.Explain("v12x")
+	require.Equal(`version: no match for v[0-9]+: [0-9]+ at offset 1: pattern ended, but "x" is left over`, e.Verdicts[2].String())
+
+	e = mg.Explain("v")
+	require.Equal(`version: no match for v[0-9]+: [0-9]+ at offset 1: input ended, expected [0-9]+`, e.Verdicts[2].String())
+}
+
+func TestExplainString(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("foo", "foo*")
+	e := b.MustCompile().Explain("fob")
+
+	require.Equal(`input "fob": no match
+  root at 0: nothing matched after this
+    foo at 0: expected "foo", found "fob"
+foo: no match for foo*: foo at offset 0: expected "foo", found "fob"
+`, e.String())
+
+	data, err := json.Marshal(e)
+	require.NoError(err)
+	require.JSONEq(`{
+		"input": "fob",
+		"matched": false,
+		"steps": [
+			{"depth": 0, "type": "root", "node": "", "offset": 0, "reason": "nothing matched after this"},
+			{"depth": 1, "type": "text", "node": "foo", "offset": 0, "reason": "expected \"foo\", found \"fob\""}
+		],
+		"verdicts": [
+			{"name": "foo", "matched": false, "pattern": "foo*", "node": "foo", "offset": 0, "reason": "expected \"foo\", found \"fob\""}
+		]
+	}`, string(data))
+}
@@ -0,0 +1,133 @@
+package multiglob
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// TreeNode is a node of the merged tree that a MultiGlob matches inputs with, as returned by
+// Tree. It encodes to JSON as is.
+type TreeNode struct {
+	ID       int         `json:"id"`              // Position of the node in a depth first walk of the tree
+	Type     string      `json:"type"`            // One of "root", "any", "text" and "range"
+	Value    string      `json:"value,omitempty"` // The text of text nodes
+	Range    *TreeRange  `json:"range,omitempty"` // The range of range nodes
+	Leaf     bool        `json:"leaf,omitempty"`  // Whether patterns end at the node
+	Names    []string    `json:"names,omitempty"` // The names of the patterns that end at the node
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// TreeRange describes the range of a TreeNode.
+type TreeRange struct {
+	Pattern  string       `json:"pattern"` // The range in canonical form, like "[^a-z]+"
+	Inverse  bool         `json:"inverse,omitempty"`
+	Repeated bool         `json:"repeated,omitempty"`
+	Chars    string       `json:"chars,omitempty"`
+	Bounds   []TreeBounds `json:"bounds,omitempty"`
+}
+
+// TreeBounds is a character range of a TreeRange, like a-z.
+type TreeBounds struct {
+	Low  string `json:"low"`
+	High string `json:"high"`
+}
+
+// Tree returns the merged tree of the MultiGlob, to show how the patterns were combined.
+func (mg *MultiGlob) Tree() *TreeNode {
+	id := 0
+	return exportNode(mg.node, &id)
+}
+
+func exportNode(n *parser.Node, id *int) *TreeNode {
+	t := &TreeNode{
+		ID:    *id,
+		Type:  nodeTypeName(n.Type),
+		Value: n.Value,
+		Leaf:  n.Leaf,
+		Names: n.Name,
+	}
+	*id++
+
+	if n.Type == parser.TypeAny {
+		// The value of any nodes is always "*"
+		t.Value = ""
+	}
+
+	if n.Range != nil {
+		t.Range = &TreeRange{
+			Pattern:  n.Range.String(),
+			Inverse:  n.Range.Inverse,
+			Repeated: n.Range.Repeated,
+			Chars:    n.Range.CharList,
+		}
+		for _, b := range n.Range.Bounds {
+			t.Range.Bounds = append(t.Range.Bounds, TreeBounds{
+				Low:  string(b.Low),
+				High: string(b.High),
+			})
+		}
+	}
+
+	for _, child := range n.Children {
+		t.Children = append(t.Children, exportNode(child, id))
+	}
+	return t
+}
+
+func nodeTypeName(t parser.NodeType) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "Type"))
+}
+
+// WriteDOT writes the merged tree of the MultiGlob to w as a Graphviz graph. Leaf nodes are
+// drawn with a double border and list the names of the patterns that end at them.
+func (mg *MultiGlob) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph multiglob {")
+	fmt.Fprintln(bw, `	node [shape=box, fontname="monospace"];`)
+	writeDOTNode(bw, mg.Tree())
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+func writeDOTNode(w io.Writer, t *TreeNode) {
+	var label string
+	switch t.Type {
+	case "text":
+		label = strconv.Quote(t.Value)
+	case "any":
+		label = "*"
+	case "range":
+		label = t.Range.Pattern
+	default:
+		label = t.Type
+	}
+
+	if t.Leaf {
+		label += "\n" + strings.Join(t.Names, ", ")
+		fmt.Fprintf(w, "\tn%d [label=%s, peripheries=2];\n", t.ID, dotQuote(label))
+	} else {
+		fmt.Fprintf(w, "\tn%d [label=%s];\n", t.ID, dotQuote(label))
+	}
+
+	for _, child := range t.Children {
+		fmt.Fprintf(w, "\tn%d -> n%d;\n", t.ID, child.ID)
+	}
+	for _, child := range t.Children {
+		writeDOTNode(w, child)
+	}
+}
+
+// dotQuote quotes s as a DOT string.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,76 @@
+package multiglob
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestTree(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("foo", "foo")
+	b.MustAddPattern("foos", "foo*")
+	b.MustAddPattern("id", "[^a-c]+")
+
+	tree := b.MustCompile().Tree()
+	require.Equal("root", tree.Type)
+	require.Len(tree.Children, 2)
+
+	// Map iteration decides the order of the children of the root
+	var foo, id *TreeNode
+	for _, child := range tree.Children {
+		if child.Type == "text" {
+			foo = child
+		} else {
+			id = child
+		}
+	}
+
+	require.Equal("foo", foo.Value)
+	require.True(foo.Leaf)
+	require.Equal([]string{"foo"}, foo.Names)
+	require.Len(foo.Children, 1)
+	require.Equal("any", foo.Children[0].Type)
+	require.Equal([]string{"foos"}, foo.Children[0].Names)
+
+	require.Equal(&TreeRange{
+		Pattern:  "[^a-c]+",
+		Inverse:  true,
+		Repeated: true,
+		Bounds:   []TreeBounds{{Low: "a", High: "c"}},
+	}, id.Range)
+
+	data, err := json.Marshal(id)
+	require.NoError(err)
+	require.JSONEq(fmt.Sprintf(`{
+		"id": %d,
+		"type": "range",
+		"range": {"pattern": "[^a-c]+", "inverse": true, "repeated": true, "bounds": [{"low": "a", "high": "c"}]},
+		"leaf": true,
+		"names": ["id"]
+	}`, id.ID), string(data))
+}
+
+func TestWriteDOT(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("foo", "foo")
+	b.MustAddPattern("foos", `fo"o*`)
+
+	var buf bytes.Buffer
+	require.NoError(b.MustCompile().WriteDOT(&buf))
+
+	dot := buf.String()
+	require.Contains(dot, "digraph multiglob {\n")
+	require.Contains(dot, "\tn0 [label=\"root\"];\n")
+	require.Contains(dot, `[label="\"foo\"\nfoo", peripheries=2];`)
+	require.Contains(dot, `[label="\"fo\\\"o\""];`)
+	require.Contains(dot, `[label="*\nfoos", peripheries=2];`)
+	require.Contains(dot, "\tn0 -> n1;\n\tn0 -> n")
+}
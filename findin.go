@@ -0,0 +1,116 @@
+package multiglob
+
+import (
+	"sync"
+	"unicode/utf8"
+
+	"github.com/szabado/multiglob/internal/automaton"
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// Occurrence is a substring of a text that a pattern matches, as the byte offsets
+// [Start, End). See MultiGlob.FindAllIn.
+type Occurrence struct {
+	Name       string
+	Start, End int
+}
+
+// FindAllOptions controls which occurrences FindAllIn returns. The zero value returns the
+// shortest occurrence at each position, without overlaps.
+type FindAllOptions struct {
+	// Overlapping returns every occurrence, even the ones that overlap others. Otherwise the
+	// text is scanned from left to right, and each occurrence picked starts after the
+	// previous one ends.
+	Overlapping bool
+
+	// LeftmostLongest picks the longest occurrence at each position instead of the shortest,
+	// like regexp.Regexp.Longest does. It's ignored for overlapping occurrences.
+	LeftmostLongest bool
+
+	// Limit is the most occurrences returned. If it isn't positive, they all are.
+	Limit int
+}
+
+// FindAllIn scans the text for every substring that a pattern matches, like
+// regexp.Regexp.FindAllStringIndex does for a single expression. Occurrences are sorted by
+// where they start and then end, and when several patterns match the same substring, each
+// gets its own occurrence, sorted by name. Empty substrings are skipped.
+//
+// Every position is tried in turn, and from each one the text is read until no pattern can
+// match any more of it. Patterns that end with a * read up to the end of the text, so scanning
+// long texts for them is quadratic.
+func (mg *MultiGlob) FindAllIn(text string, opts FindAllOptions) []Occurrence {
+	scanners := mg.scanners()
+	s := scanners.Get().(*automaton.Scanner)
+	defer scanners.Put(s)
+
+	var found []Occurrence
+	full := func() bool {
+		return opts.Limit > 0 && len(found) >= opts.Limit
+	}
+	add := func(start, end int, names []string) {
+		for _, name := range names {
+			if full() {
+				return
+			}
+			found = append(found, Occurrence{Name: name, Start: start, End: end})
+		}
+	}
+
+	for start := 0; start < len(text) && !full(); {
+		end := 0
+		var names []string
+		s.Prefixes(text[start:], func(e int, n []string) bool {
+			if e == 0 {
+				return true
+			}
+
+			switch {
+			case opts.Overlapping:
+				add(start, start+e, n)
+				return !full()
+			case opts.LeftmostLongest:
+				end, names = e, append(names[:0], n...)
+				return true
+			default:
+				end, names = e, append(names[:0], n...)
+				return false
+			}
+		})
+
+		if end > 0 {
+			add(start, start+end, names)
+			start += end
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(text[start:])
+		start += size
+	}
+
+	return found
+}
+
+// scannerCache holds the Scanners of the automaton of a merged tree, so that FindAllIn only
+// builds the automaton once.
+type scannerCache struct {
+	node     *parser.Node // The merged tree the automaton was built from
+	scanners sync.Pool
+}
+
+// scanners returns the pool of Scanners for the merged tree. The automaton is built the first
+// time it's needed, and again once RemovePattern, ReplacePattern or UnmarshalBinary replace
+// the tree.
+func (mg *MultiGlob) scanners() *sync.Pool {
+	if c := mg.scanCache.Load(); c != nil && c.node == mg.node {
+		return &c.scanners
+	}
+
+	nfa := automaton.New(mg.node)
+	c := &scannerCache{node: mg.node}
+	c.scanners.New = func() any {
+		return nfa.Scanner()
+	}
+	mg.scanCache.Store(c)
+	return &c.scanners
+}
@@ -0,0 +1,185 @@
+package multiglob
+
+import (
+	"fmt"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestFindAllIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns map[string]string
+		text     string
+		opts     FindAllOptions
+		output   []Occurrence
+	}{
+		{
+			name:     "shortest",
+			patterns: map[string]string{"err": "ERR[0-9]+"},
+			text:     "ok ERR12 fine ERR3",
+			output: []Occurrence{
+				{Name: "err", Start: 3, End: 7},
+				{Name: "err", Start: 14, End: 18},
+			},
+		},
+		{
+			name:     "leftmost longest",
+			patterns: map[string]string{"err": "ERR[0-9]+"},
+			text:     "ok ERR12 fine ERR3",
+			opts:     FindAllOptions{LeftmostLongest: true},
+			output: []Occurrence{
+				{Name: "err", Start: 3, End: 8},
+				{Name: "err", Start: 14, End: 18},
+			},
+		},
+		{
+			name:     "non-overlapping",
+			patterns: map[string]string{"aa": "aa"},
+			text:     "aaa",
+			output: []Occurrence{
+				{Name: "aa", Start: 0, End: 2},
+			},
+		},
+		{
+			name:     "overlapping",
+			patterns: map[string]string{"aa": "aa"},
+			text:     "aaa",
+			opts:     FindAllOptions{Overlapping: true},
+			output: []Occurrence{
+				{Name: "aa", Start: 0, End: 2},
+				{Name: "aa", Start: 1, End: 3},
+			},
+		},
+		{
+			name:     "overlapping patterns",
+			patterns: map[string]string{"ab": "ab", "abc": "abc", "bc": "bc"},
+			text:     "abc",
+			opts:     FindAllOptions{Overlapping: true},
+			output: []Occurrence{
+				{Name: "ab", Start: 0, End: 2},
+				{Name: "abc", Start: 0, End: 3},
+				{Name: "bc", Start: 1, End: 3},
+			},
+		},
+		{
+			name:     "longest of many patterns",
+			patterns: map[string]string{"ab": "ab", "abc": "abc", "bc": "bc"},
+			text:     "abc",
+			opts:     FindAllOptions{LeftmostLongest: true},
+			output: []Occurrence{
+				{Name: "abc", Start: 0, End: 3},
+			},
+		},
+		{
+			name:     "same substring",
+			patterns: map[string]string{"literal": "cat", "range": "c[a]t"},
+			text:     "a cat",
+			output: []Occurrence{
+				{Name: "literal", Start: 2, End: 5},
+				{Name: "range", Start: 2, End: 5},
+			},
+		},
+		{
+			name:     "empty matches skipped",
+			patterns: map[string]string{"any": "*"},
+			text:     "ab",
+			output: []Occurrence{
+				{Name: "any", Start: 0, End: 1},
+				{Name: "any", Start: 1, End: 2},
+			},
+		},
+		{
+			name:     "star",
+			patterns: map[string]string{"quoted": `"*"`},
+			text:     `say "hi" and "bye"`,
+			opts:     FindAllOptions{Overlapping: true},
+			output: []Occurrence{
+				{Name: "quoted", Start: 4, End: 8},
+				{Name: "quoted", Start: 4, End: 14},
+				{Name: "quoted", Start: 4, End: 18},
+				{Name: "quoted", Start: 7, End: 14},
+				{Name: "quoted", Start: 7, End: 18},
+				{Name: "quoted", Start: 13, End: 18},
+			},
+		},
+		{
+			name:     "multi-byte",
+			patterns: map[string]string{"word": "é[a-z]+"},
+			text:     "un été",
+			opts:     FindAllOptions{LeftmostLongest: true},
+			output: []Occurrence{
+				{Name: "word", Start: 3, End: 6},
+			},
+		},
+		{
+			name:     "multi-byte match",
+			patterns: map[string]string{"word": "[éè]t[éè]"},
+			text:     "un été",
+			output: []Occurrence{
+				{Name: "word", Start: 3, End: 8},
+			},
+		},
+		{
+			name:     "limit",
+			patterns: map[string]string{"a": "a"},
+			text:     "aaaa",
+			opts:     FindAllOptions{Limit: 3},
+			output: []Occurrence{
+				{Name: "a", Start: 0, End: 1},
+				{Name: "a", Start: 1, End: 2},
+				{Name: "a", Start: 2, End: 3},
+			},
+		},
+		{
+			name:     "no match",
+			patterns: map[string]string{"a": "a"},
+			text:     "bbb",
+			output:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			require.NoError(b.AddPatterns(test.patterns))
+			mg := b.MustCompile()
+
+			output := mg.FindAllIn(test.text, test.opts)
+			require.Equal(test.output, output)
+
+			for _, o := range output {
+				require.Contains(mg.FindAllPatterns(test.text[o.Start:o.End]), o.Name)
+			}
+		})
+	}
+}
+
+func TestFindAllInAfterUpdate(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("err", "ERR")
+	mg := b.MustCompile()
+	require.Equal([]Occurrence{{Name: "err", Start: 3, End: 6}}, mg.FindAllIn("ok ERR", FindAllOptions{}))
+
+	// The automaton is built again for the new patterns
+	require.NoError(mg.ReplacePattern("err", "ok"))
+	require.Equal([]Occurrence{{Name: "err", Start: 0, End: 2}}, mg.FindAllIn("ok ERR", FindAllOptions{}))
+}
+
+func BenchmarkFindAllIn(b *testing.B) {
+	m := New()
+	for i := 0; i < 1000; i++ {
+		m.MustAddPattern(fmt.Sprint("p", i), fmt.Sprintf("key%d-*", i))
+	}
+	mg := m.MustCompile()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mg.FindAllIn("some text with key42-abc in it", FindAllOptions{})
+	}
+}
@@ -0,0 +1,216 @@
+package multiglob
+
+import (
+	"math/rand/v2"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/automaton"
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// DefaultAlphabet is the alphabet generated inputs are drawn from when none is set.
+const DefaultAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+const (
+	defaultMaxWildcard = 8
+	nearMissAttempts   = 64
+)
+
+// GenerateOptions controls the inputs made by a Generator. The zero value uses the defaults.
+type GenerateOptions struct {
+	// MinWildcard and MaxWildcard limit the number of runes that a * produces, and the
+	// number of times a range with a + repeats. A range always produces at least one rune.
+	// MaxWildcard defaults to 8, and is raised to MinWildcard if it's smaller.
+	MinWildcard, MaxWildcard int
+
+	// Alphabet holds the runes that * produces. Ranges use the runes of the alphabet they
+	// match, or any rune they match if there are none. Defaults to DefaultAlphabet.
+	Alphabet string
+}
+
+// Generator makes random inputs that match a pattern, and near misses that don't.
+type Generator struct {
+	pattern  *parser.Node
+	nfa      *automaton.NFA
+	alphabet []rune
+	min, max int
+}
+
+// NewGenerator parses the pattern and returns a Generator for it. Syntax errors are reported
+// as in AddPattern.
+func NewGenerator(pattern string, opts GenerateOptions) (*Generator, error) {
+	p, err := parse(pattern, pattern)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create generator")
+	}
+
+	g := &Generator{
+		pattern:  p,
+		nfa:      automaton.New(p),
+		alphabet: []rune(opts.Alphabet),
+		min:      max(opts.MinWildcard, 0),
+		max:      opts.MaxWildcard,
+	}
+	if len(g.alphabet) == 0 {
+		g.alphabet = []rune(DefaultAlphabet)
+	}
+	if g.max == 0 {
+		g.max = defaultMaxWildcard
+	}
+	g.max = max(g.max, g.min)
+
+	return g, nil
+}
+
+// Generate returns a random input that matches the pattern, using the default options. See
+// NewGenerator for more control.
+func Generate(pattern string, rng *rand.Rand) (string, error) {
+	g, err := NewGenerator(pattern, GenerateOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := g.Match(rng)
+	if !ok {
+		return "", errors.Errorf("pattern %s can't match any input", pattern)
+	}
+	return s, nil
+}
+
+// Match returns a random input that matches the pattern. It returns false if the pattern
+// can't match anything.
+func (g *Generator) Match(rng *rand.Rand) (string, bool) {
+	return g.generate(rng, nil)
+}
+
+// NearMiss returns a random input that almost matches the pattern but doesn't, like a match
+// with one of its runes changed. It returns false if no such input was found, as happens for
+// patterns like "*" that match everything.
+func (g *Generator) NearMiss(rng *rand.Rand) (string, bool) {
+	var breakable []*parser.Node
+	for n := g.first(); n != nil; n = nextNode(n) {
+		if n.Type == parser.TypeRange || n.Type == parser.TypeText && n.Value != "" {
+			breakable = append(breakable, n)
+		}
+	}
+
+	for i := 0; i < nearMissAttempts; i++ {
+		var (
+			s  string
+			ok bool
+		)
+		if len(breakable) > 0 && i%2 == 0 {
+			// Break one part of the pattern
+			s, ok = g.generate(rng, breakable[rng.IntN(len(breakable))])
+		} else {
+			// Change a match a little
+			if s, ok = g.generate(rng, nil); ok {
+				s = g.mutate(rng, s)
+			}
+		}
+
+		if ok && !g.nfa.Matches(s) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// generate walks the pattern and produces an input for it. If broken is set, that node
+// produces something it doesn't match instead.
+func (g *Generator) generate(rng *rand.Rand, broken *parser.Node) (string, bool) {
+	var b strings.Builder
+	for n := g.first(); n != nil; n = nextNode(n) {
+		switch n.Type {
+		case parser.TypeText:
+			if n != broken {
+				b.WriteString(n.Value)
+				break
+			}
+
+			runes := []rune(n.Value)
+			i := rng.IntN(len(runes))
+			runes[i] = g.other(rng, runes[i])
+			b.WriteString(string(runes))
+		case parser.TypeAny:
+			for count := g.count(rng, g.min); count > 0; count-- {
+				b.WriteRune(g.alphabet[rng.IntN(len(g.alphabet))])
+			}
+		case parser.TypeRange:
+			set := automaton.FromRange(n.Range)
+			count := 1
+			if n == broken {
+				set = set.Complement()
+			} else if n.Range.Repeated {
+				count = g.count(rng, 1)
+			}
+
+			for ; count > 0; count-- {
+				r, ok := g.pick(rng, set)
+				if !ok {
+					return "", false
+				}
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String(), true
+}
+
+// first returns the first node of the pattern after the root.
+func (g *Generator) first() *parser.Node {
+	return nextNode(g.pattern)
+}
+
+// nextNode returns the node after n. Parsed patterns are a chain, so there's at most one.
+func nextNode(n *parser.Node) *parser.Node {
+	if len(n.Children) == 0 {
+		return nil
+	}
+	return n.Children[0]
+}
+
+// count returns a random number of repetitions, of at least least.
+func (g *Generator) count(rng *rand.Rand, least int) int {
+	lo, hi := max(g.min, least), max(g.max, least)
+	return lo + rng.IntN(hi-lo+1)
+}
+
+// pick returns a random rune from the set, preferring the runes of the alphabet.
+func (g *Generator) pick(rng *rand.Rand, set automaton.Set) (rune, bool) {
+	var candidates []rune
+	for _, r := range g.alphabet {
+		if set.Contains(r) {
+			candidates = append(candidates, r)
+		}
+	}
+
+	if len(candidates) > 0 {
+		return candidates[rng.IntN(len(candidates))], true
+	}
+	return set.Random(rng)
+}
+
+// other returns a random rune that isn't r.
+func (g *Generator) other(rng *rand.Rand, r rune) rune {
+	o, _ := g.pick(rng, automaton.Single(r).Complement())
+	return o
+}
+
+// mutate changes, removes or adds one rune of s.
+func (g *Generator) mutate(rng *rand.Rand, s string) string {
+	runes := []rune(s)
+	i := rng.IntN(len(runes) + 1)
+
+	switch op := rng.IntN(3); {
+	case op == 0 && i < len(runes):
+		runes[i] = g.other(rng, runes[i])
+	case op == 1 && i < len(runes):
+		runes = append(runes[:i], runes[i+1:]...)
+	default:
+		runes = append(runes[:i], append([]rune{g.alphabet[rng.IntN(len(g.alphabet))]}, runes[i:]...)...)
+	}
+	return string(runes)
+}
@@ -0,0 +1,99 @@
+package multiglob
+
+import (
+	"math/rand/v2"
+	"testing"
+	"unicode/utf8"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	patterns := []string{
+		"",
+		"foo",
+		"foo*",
+		"*.log",
+		"v[0-9]+.[0-9]",
+		"[^a-z]",
+		"id-[^0-9]+",
+		"[α-ω]x",
+		`a\*b*`,
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			b.MustAddPattern("p", pattern)
+			mg := b.MustCompile()
+
+			g, err := NewGenerator(pattern, GenerateOptions{})
+			require.NoError(err)
+
+			for i := 0; i < 100; i++ {
+				match, ok := g.Match(rng)
+				require.True(ok)
+				require.True(mg.Match(match), match)
+
+				miss, ok := g.NearMiss(rng)
+				require.True(ok)
+				require.False(mg.Match(miss), miss)
+			}
+		})
+	}
+}
+
+func TestGeneratorOptions(t *testing.T) {
+	require := r.New(t)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	g, err := NewGenerator("a*[a-z]+", GenerateOptions{
+		MinWildcard: 2,
+		MaxWildcard: 3,
+		Alphabet:    "xy",
+	})
+	require.NoError(err)
+
+	for i := 0; i < 100; i++ {
+		s, ok := g.Match(rng)
+		require.True(ok)
+		require.Regexp(`^a[xy]{2,3}[xy]{2,3}$`, s)
+	}
+
+	// The alphabet has nothing the range matches, so any rune it matches is used
+	g, err = NewGenerator("[A-C]", GenerateOptions{Alphabet: "xy"})
+	require.NoError(err)
+	s, ok := g.Match(rng)
+	require.True(ok)
+	require.Contains("ABC", s)
+}
+
+func TestGeneratorNoNearMiss(t *testing.T) {
+	require := r.New(t)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	g, err := NewGenerator("*", GenerateOptions{})
+	require.NoError(err)
+
+	s, ok := g.Match(rng)
+	require.True(ok)
+	require.True(utf8.RuneCountInString(s) <= 8)
+
+	_, ok = g.NearMiss(rng)
+	require.False(ok)
+}
+
+func TestGenerate(t *testing.T) {
+	require := r.New(t)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	s, err := Generate("foo-[0-9]", rng)
+	require.NoError(err)
+	require.Regexp(`^foo-[0-9]$`, s)
+
+	_, err = Generate("foo[", rng)
+	require.EqualError(err, "failed to create generator: failed to parse foo[: unclosed range missing ]")
+}
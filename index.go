@@ -0,0 +1,284 @@
+package multiglob
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/automaton"
+)
+
+// StringIndex is a set of strings, such as the keys of a store, that can be queried with a
+// pattern the way the KEYS and SCAN commands of Redis do. The strings are kept in a radix
+// tree, so a query only reads the parts of the tree that the pattern can still match, and the
+// prefix that strings share is only checked once. Patterns use the same syntax as AddPattern.
+//
+// A StringIndex isn't safe for concurrent use if any of the goroutines change it.
+type StringIndex struct {
+	root indexNode
+	len  int
+}
+
+// indexNode is a node of the radix tree of a StringIndex. The labels of the children of a
+// node start with different bytes, and the children are sorted by them, so walking the tree
+// depth first visits the strings in order. Labels are split on bytes rather than runes, so
+// runes are read across nodes.
+type indexNode struct {
+	label    string
+	children []*indexNode
+	key      bool // Whether a string of the set ends at the node
+}
+
+// NewStringIndex returns a new StringIndex that holds the keys.
+func NewStringIndex(keys ...string) *StringIndex {
+	x := &StringIndex{}
+	for _, key := range keys {
+		x.Insert(key)
+	}
+	return x
+}
+
+// Len returns how many strings the index holds.
+func (x *StringIndex) Len() int {
+	return x.len
+}
+
+// Insert adds the key to the index, and returns false if it was there already.
+func (x *StringIndex) Insert(key string) bool {
+	n := &x.root
+	for key != "" {
+		i, found := n.find(key[0])
+		if !found {
+			n.children = slices.Insert(n.children, i, &indexNode{label: key, key: true})
+			x.len++
+			return true
+		}
+
+		child := n.children[i]
+		common := commonPrefixLen(child.label, key)
+		if common < len(child.label) {
+			// Split the child where the key leaves its label
+			split := &indexNode{label: child.label[:common], children: []*indexNode{child}}
+			child.label = child.label[common:]
+			n.children[i] = split
+			child = split
+		}
+		n, key = child, key[common:]
+	}
+
+	if n.key {
+		return false
+	}
+	n.key = true
+	x.len++
+	return true
+}
+
+// Delete removes the key from the index, and returns false if it wasn't there.
+func (x *StringIndex) Delete(key string) bool {
+	if !x.root.remove(key) {
+		return false
+	}
+	x.len--
+	return true
+}
+
+// Contains returns true if the index holds the key.
+func (x *StringIndex) Contains(key string) bool {
+	n := &x.root
+	for key != "" {
+		i, found := n.find(key[0])
+		if !found || !strings.HasPrefix(key, n.children[i].label) {
+			return false
+		}
+		n, key = n.children[i], key[len(n.children[i].label):]
+	}
+	return n.key
+}
+
+// Query returns the strings of the index that the pattern matches, sorted. Syntax errors are
+// reported as in AddPattern, with the pattern named "query".
+func (x *StringIndex) Query(pattern string) ([]string, error) {
+	nfa, err := queryNFA(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []string
+	q := indexQuery{nfa: nfa, yield: func(key string) bool {
+		found = append(found, key)
+		return true
+	}}
+	q.walk(&x.root, nfa.Start(), 0, false)
+	return found, nil
+}
+
+// scanCursorPrefix starts every cursor other than the one that starts a scan, so that the
+// empty key can be resumed after.
+const scanCursorPrefix = ">"
+
+// Scan returns a page of at most count of the strings of the index that the pattern matches,
+// sorted, along with the cursor to pass to get the next page. Like the SCAN command of Redis,
+// a scan starts with the cursor "", and is complete once the returned cursor is "" again. If
+// count isn't positive, pages hold 10 strings.
+//
+// Cursors are opaque, but each one only records the last string returned, so the index can
+// change between pages: a string that is held for the whole scan is returned exactly once,
+// and one that's added or removed during it may or may not be.
+func (x *StringIndex) Scan(cursor, pattern string, count int) (keys []string, next string, err error) {
+	var (
+		after   []byte
+		resumed = cursor != ""
+	)
+	if resumed {
+		if !strings.HasPrefix(cursor, scanCursorPrefix) {
+			return nil, "", errors.Errorf("invalid cursor %q", cursor)
+		}
+		after = []byte(cursor[len(scanCursorPrefix):])
+	}
+	if count <= 0 {
+		count = 10
+	}
+
+	nfa, err := queryNFA(pattern)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Look for one more string than the page holds, to know if the scan is complete
+	q := indexQuery{nfa: nfa, after: after, yield: func(key string) bool {
+		keys = append(keys, key)
+		return len(keys) <= count
+	}}
+	q.walk(&x.root, nfa.Start(), 0, resumed)
+
+	if len(keys) <= count {
+		return keys, "", nil
+	}
+	keys = keys[:count]
+	return keys, scanCursorPrefix + keys[count-1], nil
+}
+
+func queryNFA(pattern string) (*automaton.NFA, error) {
+	p, err := parse("query", pattern)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse pattern")
+	}
+	return automaton.New(p), nil
+}
+
+// find returns where the child whose label starts with b is, or would be inserted.
+func (n *indexNode) find(b byte) (int, bool) {
+	i := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].label[0] >= b
+	})
+	return i, i < len(n.children) && n.children[i].label[0] == b
+}
+
+// remove removes the key from below the node, and returns whether it was there. The nodes
+// it leaves without a purpose are removed or merged into their only child, except for the
+// node itself.
+func (n *indexNode) remove(key string) bool {
+	if key == "" {
+		if !n.key {
+			return false
+		}
+		n.key = false
+		return true
+	}
+
+	i, found := n.find(key[0])
+	if !found {
+		return false
+	}
+	child := n.children[i]
+	if !strings.HasPrefix(key, child.label) || !child.remove(key[len(child.label):]) {
+		return false
+	}
+
+	switch {
+	case child.key:
+	case len(child.children) == 0:
+		n.children = slices.Delete(n.children, i, i+1)
+	case len(child.children) == 1:
+		only := child.children[0]
+		only.label = child.label + only.label
+		n.children[i] = only
+	}
+	return true
+}
+
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// indexQuery walks the radix tree of a StringIndex with the automaton of a pattern.
+type indexQuery struct {
+	nfa   *automaton.NFA
+	after []byte // When resuming a scan, only strings after this one are yielded
+	buf   []byte // The string up to the node being walked
+	yield func(key string) bool
+}
+
+// walk yields the strings at and below n that the automaton accepts, in order, and returns
+// false if yield did. The automaton is in states once it reads the runes of q.buf before
+// done, and bounded is true if q.buf is a prefix of q.after, so that some strings below n
+// may come before it.
+func (q *indexQuery) walk(n *indexNode, states []int, done int, bounded bool) bool {
+	start := len(q.buf)
+	q.buf = append(q.buf, n.label...)
+	defer func() { q.buf = q.buf[:start] }()
+
+	if bounded {
+		switch {
+		case bytes.HasPrefix(q.after, q.buf):
+			// n and the strings up to q.after come before it, or are it
+		case bytes.Compare(q.buf, q.after) > 0:
+			bounded = false
+		default:
+			return true
+		}
+	}
+
+	// Runes that aren't complete yet are read once the children add to them
+	for done < len(q.buf) && utf8.FullRune(q.buf[done:]) {
+		r, size := utf8.DecodeRune(q.buf[done:])
+		if states = q.nfa.Step(states, r); states == nil {
+			return true
+		}
+		done += size
+	}
+
+	if n.key && !bounded && q.accepts(states, done) && !q.yield(string(q.buf)) {
+		return false
+	}
+	for _, child := range n.children {
+		if !q.walk(child, states, done, bounded) {
+			return false
+		}
+	}
+	return true
+}
+
+// accepts returns true if the automaton accepts q.buf, where the bytes from done on are what's
+// left of an invalid rune.
+func (q *indexQuery) accepts(states []int, done int) bool {
+	for done < len(q.buf) {
+		r, size := utf8.DecodeRune(q.buf[done:])
+		if states = q.nfa.Step(states, r); states == nil {
+			return false
+		}
+		done += size
+	}
+	return q.nfa.Accepts(states)
+}
@@ -0,0 +1,233 @@
+package multiglob
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+
+	"github.com/szabado/multiglob/internal/automaton"
+)
+
+func TestStringIndexInsertDelete(t *testing.T) {
+	require := r.New(t)
+
+	x := NewStringIndex("user:1:session", "user:10:session", "user:1", "")
+	require.Equal(4, x.Len())
+	require.False(x.Insert("user:1"))
+	require.True(x.Insert("user:2"))
+	require.Equal(5, x.Len())
+
+	for _, key := range []string{"", "user:1", "user:2", "user:10:session"} {
+		require.True(x.Contains(key), key)
+	}
+	for _, key := range []string{"user", "user:", "user:1:", "user:3"} {
+		require.False(x.Contains(key), key)
+	}
+
+	require.True(x.Delete("user:1"))
+	require.False(x.Delete("user:1"))
+	require.False(x.Delete("user:"))
+	require.False(x.Delete("missing"))
+	require.False(x.Contains("user:1"))
+	require.True(x.Contains("user:1:session"))
+	require.Equal(4, x.Len())
+
+	for _, key := range []string{"", "user:2", "user:1:session", "user:10:session"} {
+		require.True(x.Delete(key), key)
+	}
+	require.Equal(0, x.Len())
+	require.Empty(x.root.children)
+	require.False(x.root.key)
+}
+
+func TestStringIndexDeleteMerges(t *testing.T) {
+	require := r.New(t)
+
+	x := NewStringIndex("abc", "abd", "ab")
+	require.True(x.Delete("ab"))
+	require.True(x.Delete("abd"))
+
+	// Only the root and a single node for "abc" are left
+	require.Len(x.root.children, 1)
+	require.Equal("abc", x.root.children[0].label)
+	require.Empty(x.root.children[0].children)
+}
+
+func TestStringIndexQuery(t *testing.T) {
+	x := NewStringIndex(
+		"user:1:session",
+		"user:2:session",
+		"user:2:profile",
+		"user:10:session",
+		"user::session",
+		"admin:1:session",
+		"été:1",
+		"ét\xff:1",
+		"",
+	)
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{pattern: "user:*:session", expected: []string{"user:10:session", "user:1:session", "user:2:session", "user::session"}},
+		{pattern: "user:[0-9]:*", expected: []string{"user:1:session", "user:2:profile", "user:2:session"}},
+		{pattern: "user:[0-9]+:session", expected: []string{"user:10:session", "user:1:session", "user:2:session"}},
+		{pattern: "*:1:*", expected: []string{"admin:1:session", "user:1:session"}},
+		{pattern: "ét[é]:*", expected: []string{"été:1"}},
+		{pattern: "ét?:1", expected: nil},
+		{pattern: "ét*:1", expected: []string{"été:1", "ét\xff:1"}},
+		{pattern: "", expected: []string{""}},
+		{pattern: "*", expected: x.all()},
+		{pattern: "user", expected: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			found, err := x.Query(test.pattern)
+			require.NoError(err)
+			require.Equal(test.expected, found)
+		})
+	}
+}
+
+func TestStringIndexQueryError(t *testing.T) {
+	require := r.New(t)
+
+	_, err := NewStringIndex("a").Query("[a")
+	require.Error(err)
+
+	var pe *ParseError
+	require.ErrorAs(err, &pe)
+	require.Equal("query", pe.Name)
+}
+
+// TestStringIndexQueryRandom compares queries with matching every key on its own. Keys are
+// built from pieces of runes, so that runes are split across the nodes of the tree.
+func TestStringIndexQueryRandom(t *testing.T) {
+	require := r.New(t)
+	rng := rand.New(rand.NewSource(1))
+
+	alphabet := []string{"a", "b", ":", "é", "\xc3", "\xa9"}
+	randomString := func(max int) string {
+		s := ""
+		for n := rng.Intn(max + 1); n > 0; n-- {
+			s += alphabet[rng.Intn(len(alphabet))]
+		}
+		return s
+	}
+
+	x := NewStringIndex()
+	keys := make(map[string]bool)
+	for i := 0; i < 500; i++ {
+		key := randomString(6)
+		require.Equal(!keys[key], x.Insert(key))
+		keys[key] = true
+	}
+	for i := 0; i < 100; i++ {
+		key := randomString(6)
+		require.Equal(keys[key], x.Delete(key))
+		delete(keys, key)
+	}
+	require.Equal(len(keys), x.Len())
+
+	for _, pattern := range []string{"*", "a*", "*b", "a*:*b", "[aé]+*", "*é", "[^a]*", "é:*", "*[ab]:"} {
+		p, err := parse("p", pattern)
+		require.NoError(err)
+		nfa := automaton.New(p)
+
+		var expected []string
+		for key := range keys {
+			if nfa.Matches(key) {
+				expected = append(expected, key)
+			}
+		}
+		sort.Strings(expected)
+
+		found, err := x.Query(pattern)
+		require.NoError(err)
+		require.Equal(expected, found, pattern)
+	}
+}
+
+func TestStringIndexScan(t *testing.T) {
+	require := r.New(t)
+
+	x := NewStringIndex("", "a", "ab", "abc", "b", "ba", "bab", "c")
+
+	var (
+		keys   []string
+		cursor = ""
+		pages  = 0
+	)
+	for {
+		page, next, err := x.Scan(cursor, "*", 3)
+		require.NoError(err)
+		require.LessOrEqual(len(page), 3)
+		keys = append(keys, page...)
+		pages++
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	require.Equal(x.all(), keys)
+	require.Equal(3, pages)
+
+	// The default page size
+	page, next, err := x.Scan("", "*b*", 0)
+	require.NoError(err)
+	require.Equal([]string{"ab", "abc", "b", "ba", "bab"}, page)
+	require.Equal("", next)
+
+	_, _, err = x.Scan("bogus", "*", 1)
+	require.Error(err)
+	_, _, err = x.Scan("", "[a", 1)
+	require.Error(err)
+}
+
+func TestStringIndexScanWhileChanging(t *testing.T) {
+	require := r.New(t)
+
+	x := NewStringIndex("k1", "k2", "k3", "k4", "k5")
+
+	page, cursor, err := x.Scan("", "k*", 2)
+	require.NoError(err)
+	require.Equal([]string{"k1", "k2"}, page)
+
+	// The cursor doesn't depend on the keys it was returned after
+	require.True(x.Delete("k2"))
+	require.True(x.Insert("k0"))
+	require.True(x.Insert("k25"))
+
+	page, cursor, err = x.Scan(cursor, "k*", 2)
+	require.NoError(err)
+	require.Equal([]string{"k25", "k3"}, page)
+
+	page, cursor, err = x.Scan(cursor, "k*", 2)
+	require.NoError(err)
+	require.Equal([]string{"k4", "k5"}, page)
+	require.Equal("", cursor)
+}
+
+// all returns every string of the index, in order.
+func (x *StringIndex) all() []string {
+	var keys []string
+	var walk func(n *indexNode, prefix string)
+	walk = func(n *indexNode, prefix string) {
+		prefix += n.label
+		if n.key {
+			keys = append(keys, prefix)
+		}
+		for _, child := range n.children {
+			walk(child, prefix)
+		}
+	}
+	walk(&x.root, "")
+	return keys
+}
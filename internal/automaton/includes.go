@@ -0,0 +1,139 @@
+package automaton
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Includes returns true if super accepts every input that sub accepts.
+func Includes(super, sub *NFA) bool {
+	_, found := Counterexample(super, sub)
+	return !found
+}
+
+// Counterexample returns one of the shortest inputs that sub accepts but super doesn't. It
+// returns false if there's no such input.
+func Counterexample(super, sub *NFA) (string, bool) {
+	// The states of sub are explored one at a time, and the states of super as the set
+	// they could be in after the same input.
+	type pair struct {
+		sub    int
+		super  []int
+		parent int  // Index of the pair this one was reached from, or -1
+		r      rune // The rune that led from the parent
+	}
+
+	var (
+		pairs []pair
+		seen  = make(map[string]bool)
+	)
+	push := func(subStates []int, superStates []int, parent int, r rune) {
+		for _, s := range subStates {
+			key := pairKey(s, superStates)
+			if !seen[key] {
+				seen[key] = true
+				pairs = append(pairs, pair{s, superStates, parent, r})
+			}
+		}
+	}
+
+	push(sub.closure([]int{0}), super.closure([]int{0}), -1, 0)
+	for i := 0; i < len(pairs); i++ {
+		p := pairs[i]
+
+		if sub.states[p.sub].accept && !super.accepts(p.super) {
+			var input []rune
+			for j := i; pairs[j].parent >= 0; j = pairs[j].parent {
+				input = append(input, pairs[j].r)
+			}
+			slices.Reverse(input)
+			return string(input), true
+		}
+
+		for _, e := range sub.states[p.sub].edges {
+			for _, r := range picks(split(e.set, super.edgeSets(p.super))) {
+				push(sub.closure([]int{e.to}), super.step(p.super, r), i, r)
+			}
+		}
+	}
+	return "", false
+}
+
+// picks returns a rune from each of the intervals, most readable first, so that the
+// examples found first are readable too.
+func picks(pieces []Interval) []rune {
+	runes := make([]rune, 0, len(pieces))
+	for _, piece := range pieces {
+		if r, ok := (Set{piece}).Pick(); ok {
+			runes = append(runes, r)
+		}
+	}
+
+	sort.SliceStable(runes, func(i, j int) bool {
+		return rank(runes[i]) < rank(runes[j])
+	})
+	return runes
+}
+
+// rank orders runes by how readable they are, following the preferences of Set.Pick.
+func rank(r rune) int {
+	if i := strings.IndexRune(preferred, r); i >= 0 {
+		return i
+	}
+	if unicode.IsPrint(r) {
+		return len(preferred)
+	}
+	return len(preferred) + 1
+}
+
+func pairKey(s int, states []int) string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(s))
+	b.WriteByte(':')
+	for _, t := range states {
+		b.WriteString(strconv.Itoa(t))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// edgeSets returns the sets on the edges leaving states.
+func (a *NFA) edgeSets(states []int) []Set {
+	var sets []Set
+	for _, s := range states {
+		for _, e := range a.states[s].edges {
+			sets = append(sets, e.set)
+		}
+	}
+	return sets
+}
+
+// split cuts set into intervals that each set in by either holds completely or not at all,
+// so one rune from an interval stands in for all of them.
+func split(set Set, by []Set) []Interval {
+	var cuts []rune
+	for _, s := range by {
+		for _, in := range s {
+			cuts = append(cuts, in.Lo, in.Hi+1)
+		}
+	}
+	sort.Slice(cuts, func(i, j int) bool {
+		return cuts[i] < cuts[j]
+	})
+
+	var out []Interval
+	for _, in := range set {
+		lo := in.Lo
+		for i := sort.Search(len(cuts), func(i int) bool { return cuts[i] > lo }); i < len(cuts) && cuts[i] <= in.Hi; i++ {
+			if cuts[i] > lo {
+				out = append(out, Interval{lo, cuts[i] - 1})
+				lo = cuts[i]
+			}
+		}
+		out = append(out, Interval{lo, in.Hi})
+	}
+	return out
+}
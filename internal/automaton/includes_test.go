@@ -0,0 +1,70 @@
+package automaton
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestIncludes(t *testing.T) {
+	tests := []struct {
+		super, sub string
+		output     bool
+	}{
+		{super: "*", sub: "abc", output: true},
+		{super: "abc", sub: "*", output: false},
+		{super: "a*", sub: "ab*", output: true},
+		{super: "ab*", sub: "a*", output: false},
+		{super: "*a*", sub: "*a*a*", output: true},
+		{super: "*a*a*", sub: "*a*", output: false},
+		{super: "[a-z]", sub: "[b-d]", output: true},
+		{super: "[b-d]", sub: "[a-z]", output: false},
+		{super: "[a-z]+", sub: "[a-c]", output: true},
+		{super: "[a-c]", sub: "[a-z]+", output: false},
+		{super: "[^x]", sub: "[a-w]", output: true},
+		{super: "[^x]", sub: "[a-z]", output: false},
+		{super: "*", sub: "", output: true},
+		{super: "", sub: "*", output: false},
+		{super: "*[0-9]", sub: "v[0-9]+", output: true},
+		{super: "[ab][ab]", sub: "[a][b]", output: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.super+" "+test.sub, func(t *testing.T) {
+			require := r.New(t)
+			require.Equal(test.output, Includes(mustNew(t, test.super), mustNew(t, test.sub)))
+		})
+	}
+}
+
+func TestCounterexample(t *testing.T) {
+	tests := []struct {
+		super, sub string
+		output     string
+		found      bool
+	}{
+		{super: "*", sub: "abc", found: false},
+		{super: "abc", sub: "*", output: "", found: true},
+		{super: "ab*", sub: "a*", output: "a", found: true},
+		{super: "*a*a*", sub: "*a*", output: "a", found: true},
+		{super: "[^x]", sub: "[a-z]", output: "x", found: true},
+		{super: "foo[0-9]", sub: "foo[0-9]+", output: "foo00", found: true},
+		{super: "[a-z]*", sub: "*", output: "", found: true},
+		{super: "[a-z]*", sub: "?*", output: "?", found: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.super+" "+test.sub, func(t *testing.T) {
+			require := r.New(t)
+
+			super, sub := mustNew(t, test.super), mustNew(t, test.sub)
+			output, found := Counterexample(super, sub)
+			require.Equal(test.found, found)
+			require.Equal(test.output, output)
+			if found {
+				require.True(sub.Matches(output))
+				require.False(super.Matches(output))
+			}
+		})
+	}
+}
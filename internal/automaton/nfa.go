@@ -0,0 +1,260 @@
+// Package automaton answers questions about the languages matched by patterns, such as
+// whether one pattern matches everything another does. It builds nondeterministic finite
+// automata from parsed pattern trees and explores them.
+package automaton
+
+import (
+	"strings"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// NFA is a nondeterministic finite automaton over runes. State 0 is the start state.
+type NFA struct {
+	states []state
+	sep    rune // The separator of the segments, for NewSegments
+}
+
+type state struct {
+	edges  []edge
+	text   map[rune]int // Targets of the edges added for text, by rune
+	eps    []int        // States reachable without consuming a rune
+	accept bool         // Whether the input matches once it ends in this state
+	names  []string     // Names of the patterns that match when the input ends in this state
+}
+
+type edge struct {
+	set Set
+	to  int
+}
+
+// New builds an NFA that accepts the inputs matched by the tree rooted at root. The tree can
+// be a single parsed pattern or a merged tree of many.
+func New(root *parser.Node) *NFA {
+	a := &NFA{}
+	a.newState()
+	if root != nil {
+		a.add(root, 0)
+	}
+	return a
+}
+
+// NewSegments builds an NFA for a tree returned by parser.Segment, which matches lists of
+// segments rather than strings. It accepts the segments joined with sep, so that * and ranges
+// only match within a segment, and ** matches whole segments.
+func NewSegments(root *parser.Node, sep rune) *NFA {
+	a := &NFA{sep: sep}
+	a.newState()
+	if root != nil {
+		a.add(root, 0)
+	}
+	return a
+}
+
+// within returns the runes that can appear within a segment.
+func (a *NFA) within() Set {
+	if a.sep == 0 {
+		return Full()
+	}
+	return Single(a.sep).Complement()
+}
+
+func (a *NFA) newState() int {
+	a.states = append(a.states, state{})
+	return len(a.states) - 1
+}
+
+func (a *NFA) addEdge(from, to int, set Set) {
+	a.states[from].edges = append(a.states[from].edges, edge{set: set, to: to})
+}
+
+// textState returns the state reached from "from" on the text rune r. Text that starts the
+// same way shares states like a trie does, which keeps the automaton small when the texts of
+// merged patterns only share a prefix.
+func (a *NFA) textState(from int, r rune) int {
+	if next, ok := a.states[from].text[r]; ok {
+		return next
+	}
+
+	next := a.newState()
+	a.addEdge(from, next, Single(r))
+	if a.states[from].text == nil {
+		a.states[from].text = make(map[rune]int)
+	}
+	a.states[from].text[r] = next
+	return next
+}
+
+// add adds the states for n, starting at from. The children of a node all start at the state
+// the node ends in.
+func (a *NFA) add(n *parser.Node, from int) {
+	end := from
+	switch n.Type {
+	case parser.TypeText:
+		for _, r := range n.Value {
+			end = a.textState(end, r)
+		}
+	case parser.TypeAny:
+		// The loop gets its own state so it doesn't leak into the siblings of n.
+		next := a.newState()
+		a.states[end].eps = append(a.states[end].eps, next)
+		a.addEdge(next, next, a.within())
+		end = next
+	case parser.TypeRange:
+		set := FromRange(n.Range).Intersect(a.within())
+		next := a.newState()
+		a.addEdge(end, next, set)
+		if n.Range.Repeated {
+			a.addEdge(next, next, set)
+		}
+		end = next
+	case parser.TypeSeparator:
+		for _, r := range n.Value {
+			end = a.textState(end, r)
+		}
+	case parser.TypeAnySegments:
+		end = a.addAnySegments(n.Value, end)
+	}
+
+	if n.Leaf {
+		a.states[end].accept = true
+		a.states[end].names = append(a.states[end].names, n.Name...)
+	}
+
+	for _, child := range n.Children {
+		a.add(child, end)
+	}
+}
+
+// addAnySegments adds the states for a ** that matches whole segments, starting at from, and
+// returns the state it ends in. value is ** along with the separator it absorbs, as set by
+// parser.Segment.
+func (a *NFA) addAnySegments(value string, from int) int {
+	sep := Single(a.sep)
+	switch {
+	case value == "**":
+		// Any number of segments is any input
+		next := a.newState()
+		a.states[from].eps = append(a.states[from].eps, next)
+		a.addEdge(next, next, Full())
+		return next
+	case strings.HasPrefix(value, "**"):
+		// Segments that are each followed by the separator
+		end, segment := a.newState(), a.newState()
+		a.states[from].eps = append(a.states[from].eps, end)
+		a.states[end].eps = append(a.states[end].eps, segment)
+		a.addEdge(segment, segment, a.within())
+		a.addEdge(segment, end, sep)
+		return end
+	default:
+		// Segments that each follow the separator
+		end, segment := a.newState(), a.newState()
+		a.states[from].eps = append(a.states[from].eps, end)
+		a.addEdge(end, segment, sep)
+		a.addEdge(segment, segment, a.within())
+		a.states[segment].eps = append(a.states[segment].eps, end)
+		return end
+	}
+}
+
+// closure returns the sorted states reachable from states without consuming a rune.
+func (a *NFA) closure(states []int) []int {
+	seen := make([]bool, len(a.states))
+	stack := append([]int(nil), states...)
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		stack = append(stack, a.states[s].eps...)
+	}
+
+	var out []int
+	for s, ok := range seen {
+		if ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// step returns the closure of the states reached from states on r.
+func (a *NFA) step(states []int, r rune) []int {
+	var next []int
+	for _, s := range states {
+		for _, e := range a.states[s].edges {
+			if e.set.Contains(r) {
+				next = append(next, e.to)
+			}
+		}
+	}
+	if len(next) == 0 {
+		return nil
+	}
+	return a.closure(next)
+}
+
+func (a *NFA) accepts(states []int) bool {
+	for _, s := range states {
+		if a.states[s].accept {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the NFA accepts input.
+func (a *NFA) Matches(input string) bool {
+	states := a.closure([]int{0})
+	for _, r := range input {
+		if states = a.step(states, r); states == nil {
+			return false
+		}
+	}
+	return a.accepts(states)
+}
+
+// Start returns the states the NFA is in before reading any input. Together with Step and
+// Accepts, it lets inputs that share a prefix, like the strings of a tree, share the work of
+// reading it.
+func (a *NFA) Start() []int {
+	return a.closure([]int{0})
+}
+
+// Step returns the states reached from states on r, or nil if there are none, in which case
+// no input that continues from there is accepted. The states passed in aren't changed.
+func (a *NFA) Step(states []int, r rune) []int {
+	return a.step(states, r)
+}
+
+// Accepts returns true if the NFA accepts an input that ends in states.
+func (a *NFA) Accepts(states []int) bool {
+	return a.accepts(states)
+}
+
+// Empty returns true if the NFA accepts no input at all.
+func (a *NFA) Empty() bool {
+	seen := make([]bool, len(a.states))
+	stack := []int{0}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+
+		if a.states[s].accept {
+			return false
+		}
+		stack = append(stack, a.states[s].eps...)
+		for _, e := range a.states[s].edges {
+			if !e.set.Empty() {
+				stack = append(stack, e.to)
+			}
+		}
+	}
+	return true
+}
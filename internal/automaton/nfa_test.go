@@ -0,0 +1,184 @@
+package automaton
+
+import (
+	"testing"
+	"unicode"
+
+	r "github.com/stretchr/testify/require"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// mustParse merges the patterns into one tree. Each pattern is named after itself.
+func mustParse(t *testing.T, patterns ...string) *parser.Node {
+	t.Helper()
+
+	var root *parser.Node
+	for _, pattern := range patterns {
+		p, err := parser.Parse(pattern, pattern)
+		r.NoError(t, err)
+		root = parser.Merge(root, p)
+	}
+	return root
+}
+
+func mustNew(t *testing.T, patterns ...string) *NFA {
+	t.Helper()
+	return New(mustParse(t, patterns...))
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		matches  []string
+		misses   []string
+	}{
+		{
+			patterns: []string{"a*b"},
+			matches:  []string{"ab", "axxb", "abab"},
+			misses:   []string{"a", "ba", "abc"},
+		},
+		{
+			patterns: []string{"[a-c]+x", "[^a]"},
+			matches:  []string{"ax", "abcx", "b", "z"},
+			misses:   []string{"a", "ab", "abc"},
+		},
+		{
+			// The loop of the * must not let the sibling text match later on
+			patterns: []string{"a*", "ab"},
+			matches:  []string{"a", "ab", "azzz"},
+			misses:   []string{"b", "zab"},
+		},
+		{
+			patterns: []string{""},
+			matches:  []string{""},
+			misses:   []string{"a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.patterns[0], func(t *testing.T) {
+			require := r.New(t)
+
+			a := mustNew(t, test.patterns...)
+			for _, input := range test.matches {
+				require.True(a.Matches(input), input)
+			}
+			for _, input := range test.misses {
+				require.False(a.Matches(input), input)
+			}
+		})
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	require := r.New(t)
+
+	require.False(mustNew(t, "a*").Empty())
+	require.False(mustNew(t, "").Empty())
+	require.True(New(nil).Empty())
+
+	// Patterns can't hold a NUL, so an empty range can only be built by hand
+	never := &parser.Node{
+		Type: parser.TypeRoot,
+		Children: []*parser.Node{
+			{
+				Type: parser.TypeRange,
+				Range: &parser.Range{
+					Inverse: true,
+					Bounds:  []*parser.Bounds{{Low: 0, High: unicode.MaxRune}},
+				},
+				Leaf: true,
+				Name: []string{"never"},
+			},
+		},
+	}
+	require.True(New(never).Empty())
+	require.False(New(parser.Merge(never, mustParse(t, "b"))).Empty())
+}
+
+func TestTextSharesStates(t *testing.T) {
+	require := r.New(t)
+
+	// The merged tree keeps both texts whole, but the automaton shares "service-"
+	a := mustNew(t, "service-1", "service-2")
+	require.Len(a.states, 1+len("service-")+2)
+	require.True(a.Matches("service-1"))
+	require.True(a.Matches("service-2"))
+	require.False(a.Matches("service-"))
+}
+
+func TestStep(t *testing.T) {
+	require := r.New(t)
+
+	a := mustNew(t, "ab*", "ac")
+	start := a.Start()
+	require.False(a.Accepts(start))
+
+	states := a.Step(start, 'a')
+	require.NotNil(states)
+	require.False(a.Accepts(states))
+
+	// Stepping doesn't change the states it starts from, so they can be stepped again
+	b := a.Step(states, 'b')
+	require.True(a.Accepts(b))
+	require.True(a.Accepts(a.Step(b, 'x')))
+	require.True(a.Accepts(a.Step(states, 'c')))
+	require.Nil(a.Step(states, 'x'))
+	require.Nil(a.Step(start, 'b'))
+}
+
+func TestNewSegments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		misses  []string
+	}{
+		{
+			pattern: "svc.*.p99",
+			matches: []string{"svc.api.p99", "svc..p99"},
+			misses:  []string{"svc.a.b.p99", "svc.p99"},
+		},
+		{
+			pattern: "svc.**.p99",
+			matches: []string{"svc.p99", "svc.api.p99", "svc.a.b.p99", "svc...p99"},
+			misses:  []string{"svc.p98", "svcp99", "svc.ap99"},
+		},
+		{
+			pattern: "**.p99",
+			matches: []string{"p99", "a.p99", "a.b.p99"},
+			misses:  []string{"ap99", "a.p990"},
+		},
+		{
+			pattern: "svc.**",
+			matches: []string{"svc", "svc.a", "svc.a.b"},
+			misses:  []string{"svca", "sv"},
+		},
+		{
+			pattern: "**",
+			matches: []string{"", "a", "a.b"},
+		},
+		{
+			pattern: "a[^b]c",
+			matches: []string{"axc"},
+			misses:  []string{"a.c", "abc"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			p, err := parser.Parse(test.pattern, test.pattern)
+			require.NoError(err)
+			a := NewSegments(parser.Segment(p, '.'), '.')
+
+			for _, input := range test.matches {
+				require.True(a.Matches(input), input)
+			}
+			for _, input := range test.misses {
+				require.False(a.Matches(input), input)
+			}
+		})
+	}
+}
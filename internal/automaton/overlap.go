@@ -0,0 +1,105 @@
+package automaton
+
+import (
+	"slices"
+	"sort"
+)
+
+// Overlap is a pair of patterns that both match Example.
+type Overlap struct {
+	A, B    string // Names of the patterns, with A < B
+	Example string
+}
+
+// Overlaps returns every pair of differently named patterns in the NFA that can match the
+// same input, along with one of the shortest such inputs, sorted by name.
+//
+// It runs the NFA against itself, following two paths through it at once. Paths can only
+// diverge where the patterns of the tree diverge, so patterns that start with different
+// text are never compared.
+func Overlaps(a *NFA) []Overlap {
+	type pair struct {
+		s, t   int
+		parent int  // Index of the pair this one was reached from, or -1
+		r      rune // The rune that led from the parent
+	}
+
+	var (
+		pairs []pair
+		seen  = make(map[[2]int]bool)
+		found = make(map[[2]string]int) // Index of the pair each overlap was found at
+	)
+	push := func(ss, ts []int, parent int, r rune) {
+		for _, s := range ss {
+			for _, t := range ts {
+				// Both paths are interchangeable
+				key := [2]int{min(s, t), max(s, t)}
+				if !seen[key] {
+					seen[key] = true
+					pairs = append(pairs, pair{key[0], key[1], parent, r})
+				}
+			}
+		}
+	}
+
+	start := a.closure([]int{0})
+	push(start, start, -1, 0)
+	for i := 0; i < len(pairs); i++ {
+		p := pairs[i]
+
+		for _, x := range a.states[p.s].names {
+			for _, y := range a.states[p.t].names {
+				if x == y {
+					continue
+				}
+				key := [2]string{min(x, y), max(x, y)}
+				if _, ok := found[key]; !ok {
+					found[key] = i
+				}
+			}
+		}
+
+		type step struct {
+			r      rune
+			ss, ts []int
+		}
+		var steps []step
+		for _, e1 := range a.states[p.s].edges {
+			for _, e2 := range a.states[p.t].edges {
+				if r, ok := e1.set.Intersect(e2.set).Pick(); ok {
+					steps = append(steps, step{r, a.closure([]int{e1.to}), a.closure([]int{e2.to})})
+				}
+			}
+		}
+
+		sort.SliceStable(steps, func(i, j int) bool {
+			return rank(steps[i].r) < rank(steps[j].r)
+		})
+		for _, st := range steps {
+			push(st.ss, st.ts, i, st.r)
+		}
+	}
+
+	overlaps := make([]Overlap, 0, len(found))
+	for names, i := range found {
+		var input []rune
+		for j := i; pairs[j].parent >= 0; j = pairs[j].parent {
+			input = append(input, pairs[j].r)
+		}
+		slices.Reverse(input)
+
+		overlaps = append(overlaps, Overlap{
+			A:       names[0],
+			B:       names[1],
+			Example: string(input),
+		})
+	}
+
+	sort.Slice(overlaps, func(i, j int) bool {
+		if overlaps[i].A != overlaps[j].A {
+			return overlaps[i].A < overlaps[j].A
+		}
+		return overlaps[i].B < overlaps[j].B
+	})
+	return overlaps
+}
@@ -0,0 +1,62 @@
+package automaton
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestOverlaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		output   []Overlap
+	}{
+		{
+			name:     "disjoint",
+			patterns: []string{"foo*", "bar*", "[0-9]"},
+			output:   []Overlap{},
+		},
+		{
+			name:     "prefix",
+			patterns: []string{"foo*", "foobar", "f[a-z]+"},
+			output: []Overlap{
+				{A: "f[a-z]+", B: "foo*", Example: "foo"},
+				{A: "f[a-z]+", B: "foobar", Example: "foobar"},
+				{A: "foo*", B: "foobar", Example: "foobar"},
+			},
+		},
+		{
+			name:     "wildcards",
+			patterns: []string{"*a", "b*", "*c*"},
+			output: []Overlap{
+				{A: "*a", B: "*c*", Example: "ca"},
+				{A: "*a", B: "b*", Example: "ba"},
+				{A: "*c*", B: "b*", Example: "bc"},
+			},
+		},
+		{
+			name:     "inverse",
+			patterns: []string{"[^a]", "[a]", "[ab]"},
+			output: []Overlap{
+				{A: "[^a]", B: "[ab]", Example: "b"},
+				{A: "[a]", B: "[ab]", Example: "a"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := r.New(t)
+
+			a := mustNew(t, test.patterns...)
+			output := Overlaps(a)
+			require.Equal(test.output, output)
+
+			for _, o := range output {
+				require.True(mustNew(t, o.A).Matches(o.Example))
+				require.True(mustNew(t, o.B).Matches(o.Example))
+			}
+		})
+	}
+}
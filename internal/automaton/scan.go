@@ -0,0 +1,95 @@
+package automaton
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// Scanner finds the prefixes of inputs that an NFA accepts. It reuses its buffers from one
+// input to the next, so it isn't safe for concurrent use.
+type Scanner struct {
+	a     *NFA
+	mark  []int // The generation each state was last added to a set in
+	gen   int
+	cur   []int
+	next  []int
+	stack []int
+	names []string
+}
+
+// Scanner returns a new Scanner for the NFA.
+func (a *NFA) Scanner() *Scanner {
+	return &Scanner{
+		a:    a,
+		mark: make([]int, len(a.states)),
+	}
+}
+
+// Prefixes calls yield with the end of each prefix of the input that the NFA accepts, shortest
+// first, along with the sorted names of the patterns that match it, until yield returns false.
+// The empty prefix is included. The names are only valid during the call. It stops early once
+// no longer prefix can be accepted.
+func (s *Scanner) Prefixes(input string, yield func(end int, names []string) bool) {
+	s.gen++
+	s.cur = s.add(s.cur[:0], 0)
+	for end := 0; ; {
+		if names, ok := s.accepted(); ok && !yield(end, names) {
+			return
+		}
+		if end == len(input) {
+			return
+		}
+
+		r, size := utf8.DecodeRuneInString(input[end:])
+		end += size
+
+		s.next = s.next[:0]
+		s.gen++
+		for _, st := range s.cur {
+			for _, e := range s.a.states[st].edges {
+				if e.set.Contains(r) {
+					s.next = s.add(s.next, e.to)
+				}
+			}
+		}
+		if len(s.next) == 0 {
+			return
+		}
+		s.cur, s.next = s.next, s.cur
+	}
+}
+
+// add adds the closure of st to the set of the current generation.
+func (s *Scanner) add(set []int, st int) []int {
+	s.stack = append(s.stack[:0], st)
+	for len(s.stack) > 0 {
+		st := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		if s.mark[st] == s.gen {
+			continue
+		}
+		s.mark[st] = s.gen
+
+		set = append(set, st)
+		s.stack = append(s.stack, s.a.states[st].eps...)
+	}
+	return set
+}
+
+// accepted returns the sorted names of the patterns accepted in the current set, and whether
+// any are.
+func (s *Scanner) accepted() ([]string, bool) {
+	s.names = s.names[:0]
+	ok := false
+	for _, st := range s.cur {
+		if s.a.states[st].accept {
+			s.names = append(s.names, s.a.states[st].names...)
+			ok = true
+		}
+	}
+	if len(s.names) > 1 {
+		slices.Sort(s.names)
+		s.names = slices.Compact(s.names)
+	}
+	return s.names, ok
+}
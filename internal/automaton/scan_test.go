@@ -0,0 +1,83 @@
+package automaton
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+type prefix struct {
+	end   int
+	names []string
+}
+
+func TestPrefixes(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		input    string
+		output   []prefix
+	}{
+		{
+			patterns: []string{"a", "ab", "abc"},
+			input:    "abcd",
+			output: []prefix{
+				{1, []string{"a"}},
+				{2, []string{"ab"}},
+				{3, []string{"abc"}},
+			},
+		},
+		{
+			patterns: []string{"a*", "[a-z]+"},
+			input:    "ab1",
+			output: []prefix{
+				{1, []string{"[a-z]+", "a*"}},
+				{2, []string{"[a-z]+", "a*"}},
+				{3, []string{"a*"}},
+			},
+		},
+		{
+			patterns: []string{"*"},
+			input:    "é",
+			output: []prefix{
+				{0, []string{"*"}},
+				{2, []string{"*"}},
+			},
+		},
+		{
+			patterns: []string{"b"},
+			input:    "ab",
+			output:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require := r.New(t)
+
+			s := mustNew(t, test.patterns...).Scanner()
+
+			// Scan twice, to check that nothing leaks from one input to the next
+			for range 2 {
+				var output []prefix
+				s.Prefixes(test.input, func(end int, names []string) bool {
+					output = append(output, prefix{end, append([]string(nil), names...)})
+					return true
+				})
+				require.Equal(test.output, output)
+			}
+		})
+	}
+}
+
+func TestPrefixesStop(t *testing.T) {
+	require := r.New(t)
+
+	s := mustNew(t, "a*").Scanner()
+
+	var ends []int
+	s.Prefixes("aaaa", func(end int, names []string) bool {
+		ends = append(ends, end)
+		return len(ends) < 2
+	})
+	require.Equal([]int{1, 2}, ends)
+}
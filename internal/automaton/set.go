@@ -0,0 +1,174 @@
+package automaton
+
+import (
+	"math/rand/v2"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// Surrogate halves aren't valid runes on their own, so they never show up in an input.
+const (
+	surrogateMin = 0xD800
+	surrogateMax = 0xDFFF
+)
+
+// Interval is an inclusive range of runes.
+type Interval struct {
+	Lo, Hi rune
+}
+
+// Set is a set of runes. It's kept as a sorted list of intervals that don't overlap or touch.
+type Set []Interval
+
+// Full returns the set of all runes.
+func Full() Set {
+	return Set{{0, unicode.MaxRune}}
+}
+
+// Single returns the set holding only r.
+func Single(r rune) Set {
+	return Set{{r, r}}
+}
+
+// FromRange returns the set of runes that the range matches.
+func FromRange(r *parser.Range) Set {
+	var s Set
+	for _, c := range r.CharList {
+		s = append(s, Interval{c, c})
+	}
+	for _, b := range r.Bounds {
+		s = append(s, Interval{b.Low, b.High})
+	}
+
+	s = normalize(s)
+	if r.Inverse {
+		return s.Complement()
+	}
+	return s
+}
+
+func normalize(s Set) Set {
+	if len(s) == 0 {
+		return nil
+	}
+
+	sort.Slice(s, func(i, j int) bool {
+		return s[i].Lo < s[j].Lo
+	})
+
+	out := Set{s[0]}
+	for _, in := range s[1:] {
+		last := &out[len(out)-1]
+		if in.Lo <= last.Hi+1 {
+			last.Hi = max(last.Hi, in.Hi)
+		} else {
+			out = append(out, in)
+		}
+	}
+	return out
+}
+
+// Empty returns true if the set has no runes in it.
+func (s Set) Empty() bool {
+	return len(s) == 0
+}
+
+// Contains returns true if r is in the set.
+func (s Set) Contains(r rune) bool {
+	i := sort.Search(len(s), func(i int) bool {
+		return s[i].Hi >= r
+	})
+	return i < len(s) && s[i].Lo <= r
+}
+
+// Complement returns the set of all runes that aren't in s.
+func (s Set) Complement() Set {
+	var out Set
+
+	next := rune(0)
+	for _, in := range s {
+		if in.Lo > next {
+			out = append(out, Interval{next, in.Lo - 1})
+		}
+		next = in.Hi + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, Interval{next, unicode.MaxRune})
+	}
+	return out
+}
+
+// Intersect returns the set of runes in both s and t.
+func (s Set) Intersect(t Set) Set {
+	var out Set
+	for i, j := 0, 0; i < len(s) && j < len(t); {
+		lo, hi := max(s[i].Lo, t[j].Lo), min(s[i].Hi, t[j].Hi)
+		if lo <= hi {
+			out = append(out, Interval{lo, hi})
+		}
+
+		if s[i].Hi < t[j].Hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return out
+}
+
+// preferred are the runes Pick tries first, so that examples are readable.
+const preferred = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,:;/ "
+
+// Pick returns a rune in the set, preferring readable ones. It returns false if the set has
+// no valid runes in it.
+func (s Set) Pick() (rune, bool) {
+	for _, r := range preferred {
+		if s.Contains(r) {
+			return r, true
+		}
+	}
+
+	for _, in := range s {
+		for _, r := range []rune{in.Lo, max(in.Lo, 0xE000), max(in.Lo, ' ')} {
+			if r <= in.Hi && utf8.ValidRune(r) && unicode.IsPrint(r) {
+				return r, true
+			}
+		}
+	}
+
+	for _, in := range s {
+		for _, r := range []rune{in.Lo, max(in.Lo, 0xE000)} {
+			if r <= in.Hi && utf8.ValidRune(r) {
+				return r, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Random returns a random valid rune from the set, with every one equally likely. It returns
+// false if the set has no valid runes in it.
+func (s Set) Random(rng *rand.Rand) (rune, bool) {
+	valid := s.Intersect(Set{{0, surrogateMin - 1}, {surrogateMax + 1, unicode.MaxRune}})
+
+	var size int64
+	for _, in := range valid {
+		size += int64(in.Hi-in.Lo) + 1
+	}
+	if size == 0 {
+		return 0, false
+	}
+
+	n := rng.Int64N(size)
+	for _, in := range valid {
+		if width := int64(in.Hi-in.Lo) + 1; n >= width {
+			n -= width
+		} else {
+			return in.Lo + rune(n), true
+		}
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,109 @@
+package automaton
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	r "github.com/stretchr/testify/require"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+func TestFromRange(t *testing.T) {
+	tests := []struct {
+		rnge   *parser.Range
+		output Set
+	}{
+		{
+			rnge:   &parser.Range{CharList: "cab"},
+			output: Set{{'a', 'c'}},
+		},
+		{
+			rnge: &parser.Range{
+				CharList: "z",
+				Bounds:   []*parser.Bounds{{Low: 'a', High: 'f'}, {Low: 'd', High: 'k'}},
+			},
+			output: Set{{'a', 'k'}, {'z', 'z'}},
+		},
+		{
+			rnge:   &parser.Range{CharList: "b", Inverse: true},
+			output: Set{{0, 'a'}, {'c', unicode.MaxRune}},
+		},
+		{
+			rnge: &parser.Range{
+				Inverse: true,
+				Bounds:  []*parser.Bounds{{Low: 0, High: unicode.MaxRune}},
+			},
+			output: nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			require := r.New(t)
+			require.Equal(test.output, FromRange(test.rnge))
+		})
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	require := r.New(t)
+
+	s := Set{{'a', 'f'}, {'x', 'z'}}
+	require.True(s.Contains('a'))
+	require.True(s.Contains('y'))
+	require.False(s.Contains('g'))
+	require.False(s.Contains('A'))
+
+	require.Equal(Set{{'d', 'f'}, {'x', 'x'}}, s.Intersect(Set{{'d', 'x'}}))
+	require.Equal(Set{{0, 'a' - 1}, {'g', 'w'}, {'z' + 1, unicode.MaxRune}}, s.Complement())
+	require.Equal(Full(), Set(nil).Complement())
+	require.True(Full().Complement().Empty())
+}
+
+func TestPick(t *testing.T) {
+	tests := []struct {
+		set    Set
+		output rune
+		ok     bool
+	}{
+		{set: Full(), output: 'a', ok: true},
+		{set: Set{{'q', 'z'}}, output: 'q', ok: true},
+		{set: Set{{0, 3}}, output: 0, ok: true},
+		{set: Set{{0, 3}, {'é', 'é'}}, output: 'é', ok: true},
+		{set: Set{{0xD800, 0xDFFF}}, ok: false},
+		{set: nil, ok: false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			require := r.New(t)
+
+			output, ok := test.set.Pick()
+			require.Equal(test.ok, ok)
+			require.Equal(test.output, output)
+		})
+	}
+}
+
+func TestRandom(t *testing.T) {
+	require := r.New(t)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	s := Set{{'a', 'c'}, {'x', 'x'}, {0xD800, 0xDFFF}}
+	seen := make(map[rune]bool)
+	for i := 0; i < 200; i++ {
+		c, ok := s.Random(rng)
+		require.True(ok)
+		require.True(s.Contains(c))
+		require.True(utf8.ValidRune(c))
+		seen[c] = true
+	}
+	require.Len(seen, 4)
+
+	_, ok := Set{{0xD800, 0xDFFF}}.Random(rng)
+	require.False(ok)
+}
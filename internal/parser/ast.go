@@ -1,11 +1,10 @@
 package parser
 
 import (
+	"slices"
 	"strings"
 	"unicode/utf8"
 
-	"github.com/pkg/errors"
-
 	"github.com/szabado/multiglob/internal/parser/lexer"
 )
 
@@ -17,21 +16,10 @@ const (
 	TypeAny
 	TypeText
 	TypeRange
+	TypeSeparator   // A boundary between segments, see Segment
+	TypeAnySegments // Any number of whole segments, see Segment
 )
 
-func newBounds(low, high rune) (*Bounds, error) {
-	if high < low {
-		return nil, errors.Errorf("character range (%s, %s) is out of order",
-			string(low),
-			string(high))
-	}
-
-	return &Bounds{
-		Low:  low,
-		High: high,
-	}, nil
-}
-
 type Bounds struct {
 	Low, High rune
 }
@@ -94,6 +82,8 @@ func (n *Node) canMerge(n2 *Node) bool {
 	case TypeRange:
 		// Merging ranges can be messy. Avoid it.
 		return false
+	case TypeSeparator, TypeAnySegments:
+		return n.Value == n2.Value
 	}
 	return true
 }
@@ -207,7 +197,10 @@ func (n *Node) LastIndex(s string) int {
 
 func mergeNames(n1, n2 *Node) []string {
 	if n1.Leaf && n2.Leaf {
-		return append(n1.Name, n2.Name...)
+		// Always copy: the names of n1 can be shared with trees that are still in use.
+		names := make([]string, 0, len(n1.Name)+len(n2.Name))
+		names = append(names, n1.Name...)
+		return append(names, n2.Name...)
 	} else if n1.Leaf {
 		return n1.Name
 	} else {
@@ -215,9 +208,11 @@ func mergeNames(n1, n2 *Node) []string {
 	}
 }
 
-func parse(name string, l *lexer.Lexer) (*Node, error) {
+// parse parses the rest of the lexer's input. Syntax errors are added to errs, and parsing
+// carries on past them where possible so that every error in a pattern is reported.
+func parse(name string, l *lexer.Lexer, errs *ErrorList) *Node {
 	if !l.Next() {
-		return nil, nil
+		return nil
 	}
 
 	node := &Node{}
@@ -227,6 +222,10 @@ func parse(name string, l *lexer.Lexer) (*Node, error) {
 	case lexer.Asterisk:
 		node.Type = TypeAny
 		node.Value = "*"
+		if l.Len() > 1 {
+			// Runs of asterisks match the same as one, but ** can stand for whole segments
+			node.Value = "**"
+		}
 	case lexer.Bracket:
 		if token.Value == "]" {
 			node.Value = token.Value
@@ -242,11 +241,17 @@ func parse(name string, l *lexer.Lexer) (*Node, error) {
 			parsingBounds = false
 			normalChar    = false
 			escaped       = false
+
+			// Positions for errors
+			openOffset, openIndex         = l.Offset(), l.Index()
+			previousOffset, previousIndex int
+			escapeOffset, escapeIndex     int
 		)
 
 		for finished := false; !finished; charCount++ {
 			if !l.Next() {
-				return nil, errors.New("unclosed range missing ]")
+				errs.add(UnclosedRange, openOffset, openIndex, "[")
+				break
 			}
 
 			token = l.Scan()
@@ -274,7 +279,7 @@ func parse(name string, l *lexer.Lexer) (*Node, error) {
 				} else if token.Value == "]" {
 					// Close this, handle error cases
 					if parsingBounds {
-						return nil, errors.Errorf("invalid range syntax %s-", string(previous))
+						errs.add(IncompleteBounds, previousOffset, previousIndex, string(previous)+"-")
 					}
 
 					if previousValid {
@@ -289,13 +294,16 @@ func parse(name string, l *lexer.Lexer) (*Node, error) {
 			case lexer.Backslash:
 				escaped = true
 				normalChar = false
+				escapeOffset, escapeIndex = l.Offset(), l.Index()
 			default:
 				// Treat anything unhandled as text
 				fallthrough
 			case lexer.Text:
 				normalChar = true
 				if escaped {
-					return nil, errors.Errorf(`unknown escaping: \%s`, string(token.Value[0]))
+					// Carry on as if the character wasn't escaped
+					errs.add(UnknownEscape, escapeOffset, escapeIndex, `\`+token.Value)
+					escaped = false
 				}
 			}
 
@@ -303,14 +311,17 @@ func parse(name string, l *lexer.Lexer) (*Node, error) {
 				continue
 			}
 
-			r := rune(token.Value[0])
+			r, _ := utf8.DecodeRuneInString(token.Value)
 
 			if parsingBounds {
-				b, err := newBounds(previous, r)
-				if err != nil {
-					return nil, err
+				if r < previous {
+					errs.add(ReversedBounds, previousOffset, previousIndex, string(previous)+"-"+string(r))
+				} else {
+					rnge.Bounds = append(rnge.Bounds, &Bounds{
+						Low:  previous,
+						High: r,
+					})
 				}
-				rnge.Bounds = append(rnge.Bounds, b)
 				parsingBounds = false
 			} else {
 				if previousValid {
@@ -318,6 +329,7 @@ func parse(name string, l *lexer.Lexer) (*Node, error) {
 				}
 				previous = r
 				previousValid = true
+				previousOffset, previousIndex = l.Offset(), l.Index()
 			}
 		}
 
@@ -332,19 +344,21 @@ func parse(name string, l *lexer.Lexer) (*Node, error) {
 		}
 
 	case lexer.Backslash:
+		offset, index := l.Offset(), l.Index()
 		if !l.Next() {
-			return nil, errors.New("escape found at end of pattern")
+			errs.add(TrailingEscape, offset, index, token.Value)
+			return nil
 		}
 
 		nextToken := l.Scan()
 		switch nextToken.Type {
 		case lexer.Bracket, lexer.Asterisk, lexer.Backslash:
-			node.Value = nextToken.Value
-			node.Type = TypeText
 		default:
-			r, _ := utf8.DecodeRuneInString(nextToken.Value)
-			return nil, errors.Errorf(`unknown character escaping: \%s`, string(r))
+			// Carry on as if the character wasn't escaped
+			errs.add(UnknownEscape, offset, index, token.Value+nextToken.Value)
 		}
+		node.Value = nextToken.Value
+		node.Type = TypeText
 
 		// anything other than asterisk, bracket, backslash is an error
 	case lexer.Caret, lexer.Dash, lexer.Text:
@@ -352,12 +366,7 @@ func parse(name string, l *lexer.Lexer) (*Node, error) {
 		node.Type = TypeText
 	}
 
-	child, err := parse(name, l)
-	if err != nil {
-		return nil, err
-	}
-
-	if child != nil {
+	if child := parse(name, l, errs); child != nil {
 		node.Children = []*Node{
 			child,
 		}
@@ -368,14 +377,25 @@ func parse(name string, l *lexer.Lexer) (*Node, error) {
 		node.Name = []string{name}
 	}
 
-	return node, nil
+	return node
 }
 
+// Parse parses the pattern into a tree. If the pattern has a syntax error, the error is
+// an *Error. If it has more than one, all of them are returned in an ErrorList.
 func Parse(name, input string) (*Node, error) {
 	root := newRootNode(nil)
 
-	if n, err := parse(name, lexer.New(input)); err != nil {
-		return nil, errors.Wrapf(err, "failed to parse %s", input)
+	var errs ErrorList
+	if n := parse(name, lexer.New(input), &errs); len(errs) != 0 {
+		for _, err := range errs {
+			err.Name = name
+			err.Pattern = input
+		}
+
+		if len(errs) == 1 {
+			return nil, errs[0]
+		}
+		return nil, errs
 	} else if n != nil {
 		root.Children = []*Node{n}
 	} else {
@@ -403,6 +423,41 @@ func newRootNode(children []*Node) *Node {
 	}
 }
 
+// Merge merges the tree rooted at root2 into the one rooted at root1, which is usually a merged
+// tree already. Runs of asterisks written as ** are written as * in the merged tree: only
+// Segment tells them apart, and it works from the trees of single patterns, which are left
+// alone.
 func Merge(root1, root2 *Node) *Node {
-	return root1.merge(root2)
+	return root1.merge(singleAny(root2))
+}
+
+// singleAny returns the tree rooted at n with every any node written as *. The nodes on the
+// way to a ** are copied, and the rest of the tree is shared.
+func singleAny(n *Node) *Node {
+	if n == nil {
+		return nil
+	}
+
+	var children []*Node
+	for i, child := range n.Children {
+		if c := singleAny(child); c != child {
+			if children == nil {
+				children = slices.Clone(n.Children)
+			}
+			children[i] = c
+		}
+	}
+
+	if children == nil && (n.Type != TypeAny || n.Value != "**") {
+		return n
+	}
+
+	node := *n
+	if children != nil {
+		node.Children = children
+	}
+	if node.Type == TypeAny {
+		node.Value = "*"
+	}
+	return &node
 }
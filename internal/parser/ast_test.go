@@ -1631,3 +1631,23 @@ func TestCompress(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeSingleAny(t *testing.T) {
+	require := r.New(t)
+
+	segments, err := Parse("segments", "a.**.b")
+	require.NoError(err)
+	single, err := Parse("single", "a.*.c")
+	require.NoError(err)
+
+	// The merged tree is the same whichever way the any nodes were written, and whatever the
+	// order the patterns are merged in
+	require.Equal("a.*.b\na.*.c", Merge(Merge(nil, segments), single).String())
+	require.Equal("a.*.c\na.*.b", Merge(Merge(nil, single), segments).String())
+	require.Equal("a.*.b", Merge(nil, segments).String())
+
+	// The pattern keeps its **, and the nodes after it are shared
+	require.Equal("a.**.b", segments.String())
+	merged := Merge(nil, segments)
+	require.Same(segments.Children[0].Children[0].Children[0], merged.Children[0].Children[0].Children[0])
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	flagLeaf byte = 1 << iota
+	flagRange
+	flagRepeated
+	flagInverse
+)
+
+var errTruncated = errors.New("unexpected end of data")
+
+// AppendNode appends the binary encoding of the tree rooted at n to b and returns the
+// extended buffer. A nil node is encoded too, and decodes back to nil.
+func AppendNode(b []byte, n *Node) []byte {
+	if n == nil {
+		return append(b, 0)
+	}
+
+	var flags byte
+	if n.Leaf {
+		flags |= flagLeaf
+	}
+	if n.Range != nil {
+		flags |= flagRange
+		if n.Range.Repeated {
+			flags |= flagRepeated
+		}
+		if n.Range.Inverse {
+			flags |= flagInverse
+		}
+	}
+
+	// The type is offset by one so that zero can mark a nil node
+	b = append(b, byte(n.Type)+1, flags)
+	b = appendString(b, n.Value)
+
+	b = binary.AppendUvarint(b, uint64(len(n.Name)))
+	for _, name := range n.Name {
+		b = appendString(b, name)
+	}
+
+	if n.Range != nil {
+		b = appendString(b, n.Range.CharList)
+		b = binary.AppendUvarint(b, uint64(len(n.Range.Bounds)))
+		for _, bound := range n.Range.Bounds {
+			b = binary.AppendVarint(b, int64(bound.Low))
+			b = binary.AppendVarint(b, int64(bound.High))
+		}
+	}
+
+	b = binary.AppendUvarint(b, uint64(len(n.Children)))
+	for _, child := range n.Children {
+		b = AppendNode(b, child)
+	}
+
+	return b
+}
+
+// ReadNode decodes a tree encoded by AppendNode from the beginning of b. It returns the
+// tree along with the rest of b.
+func ReadNode(b []byte) (*Node, []byte, error) {
+	if len(b) < 1 {
+		return nil, nil, errTruncated
+	}
+	if b[0] == 0 {
+		return nil, b[1:], nil
+	}
+	if len(b) < 2 {
+		return nil, nil, errTruncated
+	}
+
+	nodeType, flags := NodeType(b[0]-1), b[1]
+	if nodeType > TypeAnySegments {
+		return nil, nil, errors.Errorf("unknown node type %d", nodeType)
+	}
+	b = b[2:]
+
+	n := &Node{
+		Type: nodeType,
+		Leaf: flags&flagLeaf != 0,
+	}
+
+	var err error
+	if n.Value, b, err = readString(b); err != nil {
+		return nil, nil, err
+	}
+
+	count, b, err := readCount(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if count > 0 {
+		n.Name = make([]string, count)
+		for i := range n.Name {
+			if n.Name[i], b, err = readString(b); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if flags&flagRange != 0 {
+		n.Range = &Range{
+			Repeated: flags&flagRepeated != 0,
+			Inverse:  flags&flagInverse != 0,
+		}
+
+		if n.Range.CharList, b, err = readString(b); err != nil {
+			return nil, nil, err
+		}
+
+		if count, b, err = readCount(b); err != nil {
+			return nil, nil, err
+		}
+		for i := 0; i < count; i++ {
+			low, size := binary.Varint(b)
+			if size <= 0 {
+				return nil, nil, errTruncated
+			}
+			high, size2 := binary.Varint(b[size:])
+			if size2 <= 0 {
+				return nil, nil, errTruncated
+			}
+			b = b[size+size2:]
+
+			n.Range.Bounds = append(n.Range.Bounds, &Bounds{
+				Low:  rune(low),
+				High: rune(high),
+			})
+		}
+	}
+
+	if count, b, err = readCount(b); err != nil {
+		return nil, nil, err
+	}
+	if count > 0 {
+		n.Children = make([]*Node, count)
+		for i := range n.Children {
+			if n.Children[i], b, err = ReadNode(b); err != nil {
+				return nil, nil, err
+			}
+			if n.Children[i] == nil {
+				return nil, nil, errors.New("unexpected nil child")
+			}
+		}
+	}
+
+	return n, b, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func readString(b []byte) (string, []byte, error) {
+	length, b, err := readCount(b)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(b[:length]), b[length:], nil
+}
+
+// readCount reads a length or an element count. Every element takes up at least one byte,
+// so counts larger than the rest of b can be rejected up front.
+func readCount(b []byte) (int, []byte, error) {
+	count, size := binary.Uvarint(b)
+	if size <= 0 {
+		return 0, nil, errTruncated
+	}
+	b = b[size:]
+
+	if count > uint64(len(b)) {
+		return 0, nil, errTruncated
+	}
+	return int(count), b, nil
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestCodec(t *testing.T) {
+	tests := [][]string{
+		{""},
+		{"test"},
+		{"test*", "test*2", "*"},
+		{"[abc]+", "[^a-z0-9]", "[ä-ö]x*y"},
+		{`\[*\]`, "a", "a"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			require := r.New(t)
+
+			var final *Node
+			for inputNum, input := range test {
+				ast, err := Parse(fmt.Sprint(inputNum), input)
+				require.NoError(err)
+				final = Merge(final, ast)
+			}
+
+			b := AppendNode([]byte("prefix"), final)
+			require.Equal("prefix", string(b[:6]))
+
+			output, rest, err := ReadNode(append(b[6:], "suffix"...))
+			require.NoError(err)
+			require.Equal("suffix", string(rest))
+			require.Equal(final, output)
+		})
+	}
+}
+
+func TestCodecNil(t *testing.T) {
+	require := r.New(t)
+
+	output, rest, err := ReadNode(AppendNode(nil, nil))
+	require.NoError(err)
+	require.Nil(output)
+	require.Empty(rest)
+}
+
+func TestCodecTruncated(t *testing.T) {
+	require := r.New(t)
+
+	ast, err := Parse("test", "foo*[a-c]+bar")
+	require.NoError(err)
+
+	b := AppendNode(nil, ast)
+	for i := 0; i < len(b); i++ {
+		_, _, err := ReadNode(b[:i])
+		require.Error(err, i)
+	}
+
+	_, _, err = ReadNode([]byte{42, 0})
+	require.Error(err)
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrorKind identifies the problem described by an Error.
+type ErrorKind int
+
+const (
+	// UnclosedRange is a range without a closing bracket, like "[ab".
+	UnclosedRange ErrorKind = iota + 1
+	// ReversedBounds is a character range with its bounds out of order, like "[z-a]".
+	ReversedBounds
+	// IncompleteBounds is a character range without an upper bound, like "[a-]".
+	IncompleteBounds
+	// UnknownEscape is an escape of a character that doesn't need it, like "\a".
+	UnknownEscape
+	// TrailingEscape is an escape at the end of the pattern.
+	TrailingEscape
+)
+
+// Error describes a syntax error in a pattern.
+type Error struct {
+	Name    string    // Name of the pattern
+	Pattern string    // The pattern that failed to parse
+	Kind    ErrorKind // What the problem is
+	Offset  int       // Byte offset of Token in Pattern
+	Index   int       // Rune offset of Token in Pattern
+	Token   string    // The part of the pattern the problem is with
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("failed to parse %s: %s", e.Pattern, e.Message())
+}
+
+// Message describes the problem, without naming the pattern.
+func (e *Error) Message() string {
+	switch e.Kind {
+	case UnclosedRange:
+		return "unclosed range missing ]"
+	case ReversedBounds:
+		low, _ := utf8.DecodeRuneInString(e.Token)
+		high, _ := utf8.DecodeLastRuneInString(e.Token)
+		return fmt.Sprintf("character range (%s, %s) is out of order", string(low), string(high))
+	case IncompleteBounds:
+		return fmt.Sprintf("invalid range syntax %s", e.Token)
+	case UnknownEscape:
+		return fmt.Sprintf("unknown escaping: %s", e.Token)
+	case TrailingEscape:
+		return "escape found at end of pattern"
+	default:
+		return "unknown error"
+	}
+}
+
+// ErrorList is a list of every Error found in a pattern.
+type ErrorList []*Error
+
+func (l *ErrorList) add(kind ErrorKind, offset, index int, token string) {
+	*l = append(*l, &Error{
+		Kind:   kind,
+		Offset: offset,
+		Index:  index,
+		Token:  token,
+	})
+}
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+	}
+}
+
+// Unwrap returns the errors in the list, so that errors.As can find them.
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, err := range l {
+		errs[i] = err
+	}
+	return errs
+}
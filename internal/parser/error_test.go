@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		output *Error
+	}{
+		{
+			input: "a[b",
+			output: &Error{
+				Name:    "test",
+				Pattern: "a[b",
+				Kind:    UnclosedRange,
+				Offset:  1,
+				Index:   1,
+				Token:   "[",
+			},
+		},
+		{
+			input: "**[é-a]",
+			output: &Error{
+				Name:    "test",
+				Pattern: "**[é-a]",
+				Kind:    ReversedBounds,
+				Offset:  3,
+				Index:   3,
+				Token:   "é-a",
+			},
+		},
+		{
+			input: `ab\`,
+			output: &Error{
+				Name:    "test",
+				Pattern: `ab\`,
+				Kind:    TrailingEscape,
+				Offset:  2,
+				Index:   2,
+				Token:   `\`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require := r.New(t)
+
+			output, err := Parse("test", test.input)
+			require.Nil(output)
+			require.Equal(test.output, err)
+		})
+	}
+}
+
+func TestParseErrorRecovery(t *testing.T) {
+	require := r.New(t)
+
+	_, err := Parse("test", `\a[z-ab-]x\`)
+
+	errs, ok := err.(ErrorList)
+	require.True(ok)
+
+	kinds := make([]ErrorKind, 0, len(errs))
+	offsets := make([]int, 0, len(errs))
+	for _, e := range errs {
+		kinds = append(kinds, e.Kind)
+		offsets = append(offsets, e.Offset)
+		require.Equal("test", e.Name)
+	}
+
+	require.Equal([]ErrorKind{UnknownEscape, ReversedBounds, IncompleteBounds, TrailingEscape}, kinds)
+	require.Equal([]int{0, 3, 6, 10}, offsets)
+	require.Equal(`failed to parse \a[z-ab-]x\: unknown escaping: \a (and 3 more errors)`, err.Error())
+}
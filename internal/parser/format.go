@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// String returns the pattern the tree matches, in canonical form: redundant escapes are
+// dropped, ranges are sorted and deduplicated, and single character ranges are written as
+// text. A merged tree holds many patterns, which are returned one per line.
+//
+// Runs of asterisks match the same as a single one, so they're written as *, unless they were
+// written as ** and stand for a whole segment: see Format.
+func (n *Node) String() string {
+	return n.Format(0)
+}
+
+// Format is String for patterns that are split into segments on sep. A ** is kept where it
+// makes up a whole segment, which is where it means more than *. If sep is 0, the separator
+// isn't known, so a ** is kept where it could make up a whole segment for some separator: on
+// each side, there's either an end of the pattern or a character that isn't a letter or a
+// digit, and the same one if there's a character on both sides.
+func (n *Node) Format(sep rune) string {
+	var patterns []string
+	n.format("", nil, sep, &patterns)
+	return strings.Join(patterns, "\n")
+}
+
+// format adds the patterns of the tree to patterns. prefix is the pattern up to n, and prev is
+// the last node written before n, or nil if there's none.
+func (n *Node) format(prefix string, prev *Node, sep rune, patterns *[]string) {
+	// What comes after a node that writes nothing comes right after what came before it
+	next := n
+	switch n.Type {
+	case TypeRoot:
+		next = prev
+	case TypeText:
+		if n.Value == "" {
+			next = prev
+		}
+		prefix += n.Pattern()
+	case TypeAny:
+		if prev != nil && prev.Type == TypeAny {
+			break
+		}
+		prefix += n.Pattern()
+		if n.Value == "**" && isWholeSegment(prev, n, sep) {
+			prefix += "*"
+		}
+	default:
+		prefix += n.Pattern()
+	}
+
+	if n.Leaf {
+		*patterns = append(*patterns, prefix)
+	}
+	for _, child := range n.Children {
+		child.format(prefix, next, sep, patterns)
+	}
+}
+
+// isWholeSegment returns true if the any node n, which comes after prev, makes up a whole
+// segment when patterns are split on sep. See Format.
+func isWholeSegment(prev, n *Node, sep rune) bool {
+	before, after := rune(-1), rune(-1)
+	switch {
+	case prev == nil:
+	case prev.Type == TypeText && prev.Value != "":
+		before, _ = utf8.DecodeLastRuneInString(prev.Value)
+	default:
+		return false
+	}
+
+	switch {
+	case len(n.Children) == 0:
+	case len(n.Children) == 1 && n.Children[0].Type == TypeText && n.Children[0].Value != "":
+		after, _ = utf8.DecodeRuneInString(n.Children[0].Value)
+	default:
+		return false
+	}
+
+	for _, r := range []rune{before, after} {
+		switch {
+		case r < 0:
+		case sep != 0 && r != sep:
+			return false
+		case sep == 0 && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			return false
+		}
+	}
+	return before < 0 || after < 0 || before == after
+}
+
+// Pattern returns the part of a pattern that the node alone matches, in canonical form.
+func (n *Node) Pattern() string {
+	switch n.Type {
+	case TypeText:
+		return escapeText(n.Value)
+	case TypeAny:
+		return "*"
+	case TypeRange:
+		return formatRange(n.Range)
+	case TypeSeparator:
+		return escapeText(n.Value)
+	case TypeAnySegments:
+		// The value is ** along with the separator it stands next to
+		return n.Value
+	default:
+		return ""
+	}
+}
+
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func formatRange(r *Range) string {
+	bounds := r.normalizedBounds()
+
+	if !r.Inverse && !r.Repeated && len(bounds) == 1 && bounds[0].Low == bounds[0].High {
+		return escapeText(string(bounds[0].Low))
+	}
+	return r.String()
+}
+
+// String returns the range in canonical form, with its characters sorted and deduplicated.
+func (r *Range) String() string {
+	bounds := r.normalizedBounds()
+
+	var b strings.Builder
+	b.WriteByte('[')
+	if r.Inverse {
+		b.WriteByte('^')
+	}
+	for _, bound := range bounds {
+		writeRangeRune(&b, bound.Low)
+		switch {
+		case bound.High == bound.Low:
+		case bound.High == bound.Low+1:
+			writeRangeRune(&b, bound.High)
+		default:
+			b.WriteByte('-')
+			writeRangeRune(&b, bound.High)
+		}
+	}
+	b.WriteByte(']')
+
+	if r.Repeated {
+		b.WriteByte('+')
+	}
+	return b.String()
+}
+
+func writeRangeRune(b *strings.Builder, r rune) {
+	// A ^ only needs escaping where it would invert the range
+	if r == ']' || r == '-' || r == '^' && b.Len() == 1 {
+		b.WriteByte('\\')
+	}
+	b.WriteRune(r)
+}
+
+// normalizedBounds returns the characters and bounds of the range as sorted bounds that
+// don't overlap or touch.
+func (r *Range) normalizedBounds() []Bounds {
+	var bounds []Bounds
+	for _, c := range r.CharList {
+		bounds = append(bounds, Bounds{Low: c, High: c})
+	}
+	for _, b := range r.Bounds {
+		bounds = append(bounds, *b)
+	}
+	return normalizeBounds(bounds)
+}
+
+// normalizeBounds sorts the bounds and merges the ones that overlap or touch.
+func normalizeBounds(bounds []Bounds) []Bounds {
+	if len(bounds) == 0 {
+		return nil
+	}
+
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i].Low < bounds[j].Low
+	})
+
+	out := bounds[:1]
+	for _, b := range bounds[1:] {
+		last := &out[len(out)-1]
+		if b.Low <= last.High+1 {
+			last.High = max(last.High, b.High)
+		} else {
+			out = append(out, b)
+		}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		inputs []string
+		output string
+	}{
+		{
+			inputs: []string{"foo[cab]+*"},
+			output: "foo[a-c]+*",
+		},
+		{
+			inputs: []string{""},
+			output: "",
+		},
+		{
+			// A merged tree gives one line per pattern
+			inputs: []string{"foo", "foo*", "foobar", "[x]"},
+			output: "foo\nfoo*\nfoobar\nx",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			require := r.New(t)
+
+			var root *Node
+			for _, input := range test.inputs {
+				p, err := Parse(input, input)
+				require.NoError(err)
+				root = Merge(root, p)
+			}
+			require.Equal(test.output, root.String())
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input  string
+		sep    rune
+		output string
+	}{
+		{input: "a.**.b", sep: '.', output: "a.**.b"},
+		{input: "a.**.b", sep: '/', output: "a.*.b"},
+		{input: "a.**.b", sep: 0, output: "a.**.b"},
+		{input: "a/**.b", sep: '/', output: "a/*.b"},
+		{input: "a/**.b", sep: 0, output: "a/*.b"},
+		{input: "**.b", sep: '.', output: "**.b"},
+		{input: "a.***", sep: '.', output: "a.**"},
+		{input: "ax**", sep: 'x', output: "ax**"},
+		{input: "ax**", sep: 0, output: "ax*"},
+		{input: "a.[b]**", sep: '.', output: "a.b*"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require := r.New(t)
+
+			p, err := Parse(test.input, test.input)
+			require.NoError(err)
+			require.Equal(test.output, p.Format(test.sep))
+		})
+	}
+}
@@ -35,6 +35,9 @@ type Lexer struct {
 	source   *scanner.Scanner
 	finished bool
 	current  *Token
+	offset   int // Byte offset of the current token
+	index    int // Rune offset of the current token
+	read     int // Number of runes read so far
 }
 
 // New returns a new Lexer that wraps the given source string.
@@ -57,11 +60,16 @@ func (l *Lexer) Scan() *Token {
 // Next advances the lexer to the next token, and discards the current one. It must be called before
 // any calls to Scan.
 func (l *Lexer) Next() bool {
+	l.offset = l.source.Pos().Offset
+	l.index = l.read
+
 	r := l.source.Next()
+	l.read++
 	switch t := getTokenType(r); t {
 	case Asterisk:
 		for getTokenType(l.source.Peek()) == Asterisk {
 			l.source.Next()
+			l.read++
 		}
 		l.current = &Token{
 			Value: string(r),
@@ -87,6 +95,22 @@ func (l *Lexer) Next() bool {
 	return !l.finished
 }
 
+// Offset returns the byte offset of the current token in the source string.
+func (l *Lexer) Offset() int {
+	return l.offset
+}
+
+// Index returns the rune offset of the current token in the source string.
+func (l *Lexer) Index() int {
+	return l.index
+}
+
+// Len returns the number of runes in the current token, which is more than one for a run of
+// asterisks.
+func (l *Lexer) Len() int {
+	return l.read - l.index
+}
+
 // Peek returns the next token without consuming the current one. If the current token
 // is the last token, Peek returns nil. It can be called before the first call to Next.
 func (l *Lexer) Peek() (token *Token) {
@@ -201,3 +201,30 @@ func TestLexer(t *testing.T) {
 		})
 	}
 }
+
+func TestLexerPositions(t *testing.T) {
+	require := r.New(t)
+
+	l := New("aé**[x")
+
+	var offsets, indexes []int
+	for l.Next() {
+		offsets = append(offsets, l.Offset())
+		indexes = append(indexes, l.Index())
+	}
+
+	require.Equal([]int{0, 1, 3, 5, 6}, offsets)
+	require.Equal([]int{0, 1, 2, 4, 5}, indexes)
+}
+
+func TestLexerLen(t *testing.T) {
+	require := r.New(t)
+
+	l := New("a***[")
+
+	var lens []int
+	for l.Next() {
+		lens = append(lens, l.Len())
+	}
+	require.Equal([]int{1, 3, 1}, lens)
+}
@@ -11,11 +11,14 @@ func _() {
 	_ = x[TypeRoot-0]
 	_ = x[TypeAny-1]
 	_ = x[TypeText-2]
+	_ = x[TypeRange-3]
+	_ = x[TypeSeparator-4]
+	_ = x[TypeAnySegments-5]
 }
 
-const _NodeType_name = "TypeRootTypeAnyTypeText"
+const _NodeType_name = "TypeRootTypeAnyTypeTextTypeRangeTypeSeparatorTypeAnySegments"
 
-var _NodeType_index = [...]uint8{0, 8, 15, 23}
+var _NodeType_index = [...]uint8{0, 8, 15, 23, 32, 45, 60}
 
 func (i NodeType) String() string {
 	if i < 0 || i >= NodeType(len(_NodeType_index)-1) {
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"slices"
+)
+
+// Remove returns a copy of root with every reference to the pattern name removed.
+// Branches that only led to that pattern are pruned, and text nodes left with a single
+// text child are compressed again. root itself is never modified: any subtree that
+// doesn't lead to name is shared between root and the returned tree.
+func Remove(root *Node, name string) *Node {
+	if root == nil {
+		return nil
+	}
+
+	n, _ := root.remove(name)
+	return n
+}
+
+// remove returns the node with name removed from it and all its descendants, and whether
+// anything changed. A nil node is returned if the node no longer leads to any pattern.
+func (n *Node) remove(name string) (*Node, bool) {
+	var (
+		children []*Node
+		changed  = false
+	)
+
+	for i, child := range n.Children {
+		c, childChanged := child.remove(name)
+		if childChanged && !changed {
+			children = make([]*Node, i, len(n.Children))
+			copy(children, n.Children[:i])
+			changed = true
+		}
+
+		if changed && c != nil {
+			children = append(children, c)
+		}
+	}
+
+	if !changed {
+		children = n.Children
+	} else if len(children) == 0 {
+		children = nil
+	}
+
+	names := n.Name
+	if n.Leaf && containsName(names, name) {
+		names = make([]string, 0, len(n.Name))
+		for _, s := range n.Name {
+			if s != name {
+				names = append(names, s)
+			}
+		}
+		// Matching appends to the names of leaves, which mustn't write to the spare capacity
+		names = slices.Clip(names)
+		changed = true
+	}
+
+	if !changed {
+		return n, false
+	}
+
+	leaf := n.Leaf && len(names) != 0
+	if !leaf {
+		names = nil
+	}
+
+	if !leaf && children == nil && n.Type != TypeRoot {
+		return nil, true
+	}
+
+	node := &Node{
+		Type:     n.Type,
+		Value:    n.Value,
+		Children: children,
+		Leaf:     leaf,
+		Name:     names,
+		Range:    n.Range,
+	}
+
+	// Only node is new here, so this has to avoid compress: it would modify the shared
+	// children instead of copying them.
+	if node.Type == TypeText && !node.Leaf && len(node.Children) == 1 {
+		if child := node.Children[0]; child.Type == TypeText {
+			node.Value += child.Value
+			node.Children = child.Children
+			node.Leaf = child.Leaf
+			node.Name = child.Name
+		}
+	}
+
+	return node, true
+}
+
+func containsName(names []string, name string) bool {
+	for _, s := range names {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
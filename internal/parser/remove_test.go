@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		inputs []string
+		remove string
+		output *Node
+	}{
+		{
+			inputs: []string{
+				"test",
+				"test*",
+			},
+			remove: "1",
+			output: &Node{
+				Value: "",
+				Type:  TypeRoot,
+				Children: []*Node{
+					{
+						Children: nil,
+						Value:    "test",
+						Type:     TypeText,
+						Leaf:     true,
+						Name:     []string{"0"},
+					},
+				},
+			},
+		},
+		{
+			inputs: []string{
+				"test",
+				"test*",
+			},
+			remove: "0",
+			output: &Node{
+				Value: "",
+				Type:  TypeRoot,
+				Children: []*Node{
+					{
+						Value: "test",
+						Type:  TypeText,
+						Children: []*Node{
+							{
+								Children: nil,
+								Value:    "*",
+								Type:     TypeAny,
+								Leaf:     true,
+								Name:     []string{"1"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			inputs: []string{
+				"a*b",
+				"a*c",
+				"d",
+			},
+			remove: "1",
+			output: &Node{
+				Value: "",
+				Type:  TypeRoot,
+				Children: []*Node{
+					{
+						Value: "a",
+						Type:  TypeText,
+						Children: []*Node{
+							{
+								Value: "*",
+								Type:  TypeAny,
+								Children: []*Node{
+									{
+										Children: nil,
+										Value:    "b",
+										Type:     TypeText,
+										Leaf:     true,
+										Name:     []string{"0"},
+									},
+								},
+							},
+						},
+					},
+					{
+						Children: nil,
+						Value:    "d",
+						Type:     TypeText,
+						Leaf:     true,
+						Name:     []string{"2"},
+					},
+				},
+			},
+		},
+		{
+			inputs: []string{
+				"[ab]",
+			},
+			remove: "0",
+			output: &Node{
+				Value:    "",
+				Type:     TypeRoot,
+				Children: nil,
+			},
+		},
+		{
+			inputs: []string{
+				"a",
+			},
+			remove: "missing",
+			output: &Node{
+				Value: "",
+				Type:  TypeRoot,
+				Children: []*Node{
+					{
+						Children: nil,
+						Value:    "a",
+						Type:     TypeText,
+						Leaf:     true,
+						Name:     []string{"0"},
+					},
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			require := r.New(t)
+
+			var final *Node
+			for inputNum, input := range test.inputs {
+				ast, err := Parse(fmt.Sprint(inputNum), input)
+				require.NoError(err)
+				final = Merge(final, ast)
+			}
+
+			require.Equal(test.output, Remove(final, test.remove))
+		})
+	}
+}
+
+func TestRemoveLeavesOriginal(t *testing.T) {
+	require := r.New(t)
+
+	astA, err := Parse("A", "ab*")
+	require.NoError(err)
+	astB, err := Parse("B", "ab")
+	require.NoError(err)
+
+	merged := Merge(astA, astB)
+	before := fmt.Sprintf("%#v", *merged.Children[0])
+
+	removed := Remove(merged, "B")
+	require.Equal(before, fmt.Sprintf("%#v", *merged.Children[0]))
+	require.False(removed.Children[0].Leaf)
+	require.Same(merged.Children[0].Children[0], removed.Children[0].Children[0])
+}
+
+func TestRemoveNil(t *testing.T) {
+	require := r.New(t)
+	require.Nil(Remove(nil, "test"))
+}
+
+func TestRemoveClipsNames(t *testing.T) {
+	require := r.New(t)
+
+	var merged *Node
+	for _, name := range []string{"A", "B", "C"} {
+		ast, err := Parse(name, "ab")
+		require.NoError(err)
+		merged = Merge(merged, ast)
+	}
+
+	// Appending to the names of the leaf mustn't write past them, into memory they share
+	leaf := Remove(merged, "B").Children[0]
+	require.Equal([]string{"A", "C"}, leaf.Name)
+	require.Equal(len(leaf.Name), cap(leaf.Name))
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+)
+
+// Segment returns a copy of a parsed pattern that matches lists of segments, such as the
+// parts of a name split on sep, instead of strings. The text of the pattern is split on sep
+// into TypeSeparator nodes, which match the boundary between two segments, so that the other
+// nodes only ever match within a segment. A segment of the pattern that is just ** becomes a
+// TypeAnySegments node, which matches any number of whole segments, including none. Its value
+// is ** along with the separator it stands next to, which it absorbs: "**" and sep when it
+// comes first or in the middle, sep and "**" when it comes last, and only "**" when it's the
+// whole pattern.
+//
+// Like the tree returned by Parse, the tree is a chain that can be merged with others.
+func Segment(root *Node, sep rune) *Node {
+	// Split the chain into segments, leaving out the roots
+	var (
+		segments = [][]*Node{nil}
+		names    []string
+	)
+	for n := root; n != nil; {
+		switch n.Type {
+		case TypeText:
+			parts := strings.Split(n.Value, string(sep))
+			for i, part := range parts {
+				if i > 0 {
+					segments = append(segments, nil)
+				}
+				if part != "" || len(parts) == 1 {
+					last := &segments[len(segments)-1]
+					*last = append(*last, &Node{Type: TypeText, Value: part})
+				}
+			}
+		case TypeAny:
+			last := &segments[len(segments)-1]
+			*last = append(*last, &Node{Type: TypeAny, Value: n.Value})
+		case TypeRange:
+			last := &segments[len(segments)-1]
+			*last = append(*last, &Node{Type: TypeRange, Range: n.Range})
+		}
+
+		if n.Leaf {
+			names = n.Name
+		}
+		if len(n.Children) == 0 {
+			break
+		}
+		n = n.Children[0]
+	}
+
+	// A ** right after another adds nothing
+	segments = slices.CompactFunc(segments, func(a, b []*Node) bool {
+		return isAnySegments(a) && isAnySegments(b)
+	})
+
+	var (
+		out  = newRootNode(nil)
+		tail = out
+		add  = func(n *Node) {
+			tail.Children = []*Node{n}
+			tail = n
+		}
+	)
+	for i, segment := range segments {
+		if isAnySegments(segment) {
+			switch {
+			case len(segments) == 1:
+				add(&Node{Type: TypeAnySegments, Value: "**"})
+			case i < len(segments)-1:
+				if i > 0 {
+					add(&Node{Type: TypeSeparator, Value: string(sep)})
+				}
+				add(&Node{Type: TypeAnySegments, Value: "**" + string(sep)})
+			default:
+				add(&Node{Type: TypeAnySegments, Value: string(sep) + "**"})
+			}
+			continue
+		}
+
+		// The separator before a segment is absorbed by a ** that comes first or in the middle
+		if i > 0 && !isAnySegments(segments[i-1]) {
+			add(&Node{Type: TypeSeparator, Value: string(sep)})
+		}
+		for _, n := range segment {
+			if n.Type == TypeAny {
+				// Within a segment, ** is only a *
+				n.Value = "*"
+			}
+			add(n)
+		}
+	}
+
+	tail.Leaf = true
+	tail.Name = names
+	return out
+}
+
+// isAnySegments returns true if a segment is only **.
+func isAnySegments(segment []*Node) bool {
+	return len(segment) == 1 && segment[0].Type == TypeAny && segment[0].Value == "**"
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestSegment(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+		types  []NodeType
+	}{
+		{
+			input:  "svc.*.latency",
+			output: "svc.*.latency",
+			types:  []NodeType{TypeRoot, TypeText, TypeSeparator, TypeAny, TypeSeparator, TypeText},
+		},
+		{
+			input:  "a.**.b",
+			output: "a.**.b",
+			types:  []NodeType{TypeRoot, TypeText, TypeSeparator, TypeAnySegments, TypeText},
+		},
+		{
+			input:  "**.b",
+			output: "**.b",
+			types:  []NodeType{TypeRoot, TypeAnySegments, TypeText},
+		},
+		{
+			input:  "a.**",
+			output: "a.**",
+			types:  []NodeType{TypeRoot, TypeText, TypeAnySegments},
+		},
+		{
+			input:  "**",
+			output: "**",
+			types:  []NodeType{TypeRoot, TypeAnySegments},
+		},
+		{
+			// Repeated ** are the same as one
+			input:  "a.**.**.b",
+			output: "a.**.b",
+			types:  []NodeType{TypeRoot, TypeText, TypeSeparator, TypeAnySegments, TypeText},
+		},
+		{
+			// ** within a segment is only a *
+			input:  "a**.b",
+			output: "a*.b",
+			types:  []NodeType{TypeRoot, TypeText, TypeAny, TypeSeparator, TypeText},
+		},
+		{
+			input:  "a..[xy]+",
+			output: "a..[xy]+",
+			types:  []NodeType{TypeRoot, TypeText, TypeSeparator, TypeSeparator, TypeRange},
+		},
+		{
+			input:  "",
+			output: "",
+			types:  []NodeType{TypeRoot, TypeText},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require := r.New(t)
+
+			p, err := Parse("name", test.input)
+			require.NoError(err)
+
+			s := Segment(p, '.')
+			require.Equal(test.output, s.String())
+
+			var types []NodeType
+			for n := s; n != nil; {
+				types = append(types, n.Type)
+				if len(n.Children) == 0 {
+					require.True(n.Leaf)
+					require.Equal([]string{"name"}, n.Name)
+					break
+				}
+				require.Len(n.Children, 1)
+				require.False(n.Leaf)
+				n = n.Children[0]
+			}
+			require.Equal(test.types, types)
+		})
+	}
+}
+
+func TestSegmentMerge(t *testing.T) {
+	require := r.New(t)
+
+	var root *Node
+	for _, pattern := range []string{"svc.api.*", "svc.web.*", "svc.**"} {
+		p, err := Parse(pattern, pattern)
+		require.NoError(err)
+		root = Merge(root, Segment(p, '.'))
+	}
+
+	// The segments share "svc", and the separators after it
+	require.Len(root.Children, 1)
+	svc := root.Children[0]
+	require.Equal(TypeText, svc.Type)
+	require.Equal("svc", svc.Value)
+	require.Len(svc.Children, 2)
+
+	sep := svc.Children[0]
+	require.Equal(TypeSeparator, sep.Type)
+	require.Len(sep.Children, 2)
+	require.Equal(TypeAnySegments, svc.Children[1].Type)
+
+	require.Equal("svc.api.*\nsvc.web.*\nsvc.**", root.String())
+}
@@ -0,0 +1,315 @@
+package multiglob
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/automaton"
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// LintKind identifies the problem described by a LintFinding.
+type LintKind int
+
+const (
+	// LintInvalid is a pattern that can't be parsed.
+	LintInvalid LintKind = iota + 1
+	// LintSuspicious is a construct that's allowed, or that could be a typo, but probably
+	// doesn't do what was intended. Examples are reversed bounds like "[z-a]", an unescaped
+	// "]" outside of a range, and a "\" at the end of a pattern.
+	LintSuspicious
+	// LintNeverMatches is a pattern that no input can match, like an empty negated range.
+	LintNeverMatches
+	// LintDuplicate is a pattern that's identical to one with a higher priority.
+	LintDuplicate
+	// LintShadowed is a pattern that only matches inputs that a pattern with a higher
+	// priority matches as well. See Builder.Lint for the priority of patterns.
+	LintShadowed
+)
+
+func (k LintKind) String() string {
+	switch k {
+	case LintInvalid:
+		return "invalid"
+	case LintSuspicious:
+		return "suspicious"
+	case LintNeverMatches:
+		return "never-matches"
+	case LintDuplicate:
+		return "duplicate"
+	case LintShadowed:
+		return "shadowed"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler, so that findings encode to readable JSON.
+func (k LintKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// LintFinding is a problem found by Lint.
+type LintFinding struct {
+	Kind    LintKind `json:"kind"`
+	Name    string   `json:"name"`            // Name of the pattern with the problem
+	Pattern string   `json:"pattern"`         // The pattern with the problem
+	Offset  int      `json:"offset"`          // Byte offset of the problem in Pattern, or -1 if it's the whole pattern
+	Other   string   `json:"other,omitempty"` // For LintDuplicate and LintShadowed, the name of the earlier pattern
+	Message string   `json:"message"`
+}
+
+func (f LintFinding) String() string {
+	if f.Offset < 0 {
+		return fmt.Sprintf("%s: %s: %s", f.Name, f.Kind, f.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", f.Name, f.Offset, f.Kind, f.Message)
+}
+
+// LintPattern checks a single pattern for problems that don't depend on other patterns.
+func LintPattern(name, pattern string) []LintFinding {
+	findings := lintSource(name, pattern)
+
+	p, err := parse(name, pattern)
+	if err != nil {
+		var errs ParseErrors
+		if !errors.As(err, &errs) {
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				return append(findings, LintFinding{
+					Kind:    LintInvalid,
+					Name:    name,
+					Pattern: pattern,
+					Offset:  -1,
+					Message: err.Error(),
+				})
+			}
+			errs = ParseErrors{pe}
+		}
+
+		for _, pe := range errs {
+			kind := LintInvalid
+			if pe.Kind == ParseReversedBounds || pe.Kind == ParseTrailingEscape {
+				kind = LintSuspicious
+			}
+			findings = append(findings, LintFinding{
+				Kind:    kind,
+				Name:    name,
+				Pattern: pattern,
+				Offset:  pe.Offset,
+				Message: pe.err.Message(),
+			})
+		}
+		return findings
+	}
+
+	if automaton.New(p).Empty() {
+		findings = append(findings, LintFinding{
+			Kind:    LintNeverMatches,
+			Name:    name,
+			Pattern: pattern,
+			Offset:  -1,
+			Message: "no input can match the pattern",
+		})
+	}
+	return findings
+}
+
+// lintSource looks for an unescaped "]" outside of a range, which the parser accepts as text.
+func lintSource(name, pattern string) []LintFinding {
+	var (
+		findings  []LintFinding
+		inRange   = false
+		escaped   = false
+		charCount = 0
+		inverse   = false
+	)
+
+	for offset, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+			charCount++
+		case r == '\\':
+			escaped = true
+		case !inRange && r == '[':
+			inRange, charCount, inverse = true, 0, false
+		case !inRange && r == ']':
+			findings = append(findings, LintFinding{
+				Kind:    LintSuspicious,
+				Name:    name,
+				Pattern: pattern,
+				Offset:  offset,
+				Message: `unescaped "]" outside of a range matches itself, use "\]" instead`,
+			})
+		case inRange && r == '^' && charCount == 0:
+			inverse = true
+			charCount++
+		case inRange && r == ']' && charCount > 0 && !(charCount == 1 && inverse):
+			inRange = false
+		case inRange:
+			charCount++
+		}
+	}
+	return findings
+}
+
+// lintEntry is one pattern of a Builder, in priority order.
+type lintEntry struct {
+	name, pattern string
+	ast           *parser.Node // The pattern split into segments, if the builder has a separator
+	nfa           *automaton.NFA
+	segments      *automaton.NFA // The NFA of ast for MatchSegments, if the builder has a separator
+	prefix        string
+	suffix        string
+	never         bool
+}
+
+// Lint checks all the patterns in the builder for problems, and returns what it finds in
+// priority order: names in the order they were first added, and the patterns kept under each
+// name in the order they were added. Besides the problems found by LintPattern, it reports
+// patterns that duplicate or are shadowed by patterns added before them. If the builder has a
+// separator, patterns are compared as lists of segments as well, and a pattern is only reported
+// if it's a duplicate or shadowed for both Match and MatchSegments.
+func (m *Builder) Lint() []LintFinding {
+	var (
+		findings []LintFinding
+		entries  []*lintEntry
+	)
+
+	for _, name := range m.order {
+		for i, pattern := range m.sources[name] {
+			found := LintPattern(name, pattern)
+			findings = append(findings, found...)
+
+			ast := m.patterns[name][i]
+			e := &lintEntry{
+				name:    name,
+				pattern: pattern,
+				ast:     ast,
+				nfa:     automaton.New(ast),
+			}
+			if m.separator != 0 {
+				e.ast = parser.Segment(ast, m.separator)
+				e.segments = automaton.NewSegments(e.ast, m.separator)
+			}
+			e.prefix, e.suffix = literalPrefix(e.ast), literalSuffix(e.ast)
+			for _, f := range found {
+				e.never = e.never || f.Kind == LintNeverMatches
+			}
+
+			if f, ok := lintAgainst(e, entries); ok {
+				findings = append(findings, f)
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	return findings
+}
+
+// lintAgainst compares e with the patterns that have a higher priority, and reports the
+// first one it duplicates or is shadowed by.
+func lintAgainst(e *lintEntry, earlier []*lintEntry) (LintFinding, bool) {
+	if e.never {
+		// Everything shadows a pattern that can't match, which is reported already
+		return LintFinding{}, false
+	}
+
+	for _, other := range earlier {
+		if equalIgnoringNames(e.ast, other.ast) {
+			return LintFinding{
+				Kind:    LintDuplicate,
+				Name:    e.name,
+				Pattern: e.pattern,
+				Offset:  -1,
+				Other:   other.name,
+				Message: fmt.Sprintf("same pattern as %s", other.name),
+			}, true
+		}
+	}
+
+	for _, other := range earlier {
+		// Patterns whose literal prefixes or suffixes conflict can't have an input in
+		// common, which rules most pairs out cheaply.
+		if other.never || !compatible(e.prefix, other.prefix, strings.HasPrefix) ||
+			!compatible(e.suffix, other.suffix, strings.HasSuffix) {
+			continue
+		}
+
+		if automaton.Includes(other.nfa, e.nfa) &&
+			(e.segments == nil || automaton.Includes(other.segments, e.segments)) {
+			return LintFinding{
+				Kind:    LintShadowed,
+				Name:    e.name,
+				Pattern: e.pattern,
+				Offset:  -1,
+				Other:   other.name,
+				Message: fmt.Sprintf("every input it matches is matched by %s, which has a higher priority", other.name),
+			}, true
+		}
+	}
+
+	return LintFinding{}, false
+}
+
+// compatible returns true if either string is an affix of the other.
+func compatible(a, b string, hasAffix func(s, affix string) bool) bool {
+	return hasAffix(a, b) || hasAffix(b, a)
+}
+
+// literalPrefix returns the text a parsed pattern starts with.
+func literalPrefix(ast *parser.Node) string {
+	if len(ast.Children) == 0 || ast.Children[0].Type != parser.TypeText {
+		return ""
+	}
+	return ast.Children[0].Value
+}
+
+// literalSuffix returns the text a parsed pattern ends with.
+func literalSuffix(ast *parser.Node) string {
+	n := ast
+	for len(n.Children) > 0 {
+		n = n.Children[0]
+	}
+
+	if n.Type != parser.TypeText {
+		return ""
+	}
+	return n.Value
+}
+
+func equalIgnoringNames(a, b *parser.Node) bool {
+	if a.Type != b.Type || a.Leaf != b.Leaf || len(a.Children) != len(b.Children) {
+		return false
+	}
+	// Any nodes match the same whether they were written as * or **. Where a ** means more,
+	// the trees are split into segments, and it's an any segments node instead.
+	if a.Type != parser.TypeAny && a.Value != b.Value {
+		return false
+	}
+
+	if (a.Range == nil) != (b.Range == nil) {
+		return false
+	}
+	if a.Range != nil {
+		if a.Range.Repeated != b.Range.Repeated || a.Range.Inverse != b.Range.Inverse ||
+			a.Range.CharList != b.Range.CharList || len(a.Range.Bounds) != len(b.Range.Bounds) {
+			return false
+		}
+		for i := range a.Range.Bounds {
+			if *a.Range.Bounds[i] != *b.Range.Bounds[i] {
+				return false
+			}
+		}
+	}
+
+	for i := range a.Children {
+		if !equalIgnoringNames(a.Children[i], b.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,209 @@
+package multiglob
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestLintPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		findings []LintFinding
+	}{
+		{
+			pattern: "foo*[a-z]+",
+		},
+		{
+			pattern: "foo]",
+			findings: []LintFinding{
+				{Kind: LintSuspicious, Offset: 3, Message: `unescaped "]" outside of a range matches itself, use "\]" instead`},
+			},
+		},
+		{
+			// Brackets inside ranges and escaped ones are fine
+			pattern: `[]a][^]]\]`,
+		},
+		{
+			pattern: "foo[z-a]",
+			findings: []LintFinding{
+				{Kind: LintSuspicious, Offset: 4, Message: "character range (z, a) is out of order"},
+			},
+		},
+		{
+			pattern: `foo\`,
+			findings: []LintFinding{
+				{Kind: LintSuspicious, Offset: 3, Message: "escape found at end of pattern"},
+			},
+		},
+		{
+			pattern: "foo[ab",
+			findings: []LintFinding{
+				{Kind: LintInvalid, Offset: 3, Message: "unclosed range missing ]"},
+			},
+		},
+		{
+			pattern: "a]b[z-a]",
+			findings: []LintFinding{
+				{Kind: LintSuspicious, Offset: 1, Message: `unescaped "]" outside of a range matches itself, use "\]" instead`},
+				{Kind: LintSuspicious, Offset: 4, Message: "character range (z, a) is out of order"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			for i := range test.findings {
+				test.findings[i].Name = "name"
+				test.findings[i].Pattern = test.pattern
+			}
+			require.Equal(test.findings, LintPattern("name", test.pattern))
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.SetDuplicatePolicy(DuplicateKeepAll)
+	b.MustAddPattern("logs", "logs/*")
+	b.MustAddPattern("errors", "logs/*.err")
+	b.MustAddPattern("copy", "logs/*")
+	b.MustAddPattern("digits", "v[0-9]+")
+	b.MustAddPattern("digit", "v[3]")
+	b.MustAddPattern("broader", "*")
+	b.MustAddPattern("bracket", "a]")
+	b.MustAddPattern("logs", "logs/a*")
+
+	require.Equal([]LintFinding{
+		{
+			Kind:    LintShadowed,
+			Name:    "logs",
+			Pattern: "logs/a*",
+			Offset:  -1,
+			Other:   "logs",
+			Message: "every input it matches is matched by logs, which has a higher priority",
+		},
+		{
+			Kind:    LintShadowed,
+			Name:    "errors",
+			Pattern: "logs/*.err",
+			Offset:  -1,
+			Other:   "logs",
+			Message: "every input it matches is matched by logs, which has a higher priority",
+		},
+		{
+			Kind:    LintDuplicate,
+			Name:    "copy",
+			Pattern: "logs/*",
+			Offset:  -1,
+			Other:   "logs",
+			Message: "same pattern as logs",
+		},
+		{
+			Kind:    LintShadowed,
+			Name:    "digit",
+			Pattern: "v[3]",
+			Offset:  -1,
+			Other:   "digits",
+			Message: "every input it matches is matched by digits, which has a higher priority",
+		},
+		{
+			Kind:    LintSuspicious,
+			Name:    "bracket",
+			Pattern: "a]",
+			Offset:  1,
+			Message: `unescaped "]" outside of a range matches itself, use "\]" instead`,
+		},
+		{
+			Kind:    LintShadowed,
+			Name:    "bracket",
+			Pattern: "a]",
+			Offset:  -1,
+			Other:   "broader",
+			Message: "every input it matches is matched by broader, which has a higher priority",
+		},
+	}, b.Lint())
+}
+
+func TestLintAnySegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		sep      rune
+		patterns []string // Added under the names p0, p1...
+		expected []LintKind
+	}{
+		{
+			name:     "** is * without a separator",
+			patterns: []string{"a*b", "a**b"},
+			expected: []LintKind{LintDuplicate},
+		},
+		{
+			name:     "** within a segment is *",
+			sep:      '.',
+			patterns: []string{"a.b*", "a.b**"},
+			expected: []LintKind{LintDuplicate},
+		},
+		{
+			name:     "** matches more segments than *",
+			sep:      '.',
+			patterns: []string{"svc.*.p99", "svc.**.p99"},
+		},
+		{
+			name:     "* is one of the segments ** matches",
+			sep:      '.',
+			patterns: []string{"svc.**.p99", "svc.*.p99"},
+			expected: []LintKind{LintShadowed},
+		},
+		{
+			name:     "* doesn't match across segments",
+			sep:      '.',
+			patterns: []string{"svc.*", "svc.a.b"},
+		},
+		{
+			name:     "* matches across separators without segments",
+			patterns: []string{"svc.*", "svc.a.b"},
+			expected: []LintKind{LintShadowed},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			if test.sep != 0 {
+				require.NoError(b.SetSeparator(test.sep))
+			}
+			for i, pattern := range test.patterns {
+				b.MustAddPattern(fmt.Sprint("p", i), pattern)
+			}
+
+			var kinds []LintKind
+			for _, f := range b.Lint() {
+				kinds = append(kinds, f.Kind)
+			}
+			require.Equal(test.expected, kinds)
+		})
+	}
+}
+
+func TestLintFindingJSON(t *testing.T) {
+	require := r.New(t)
+
+	data, err := json.Marshal(LintFinding{
+		Kind:    LintDuplicate,
+		Name:    "b",
+		Pattern: "a*",
+		Offset:  -1,
+		Other:   "a",
+		Message: "same pattern as a",
+	})
+	require.NoError(err)
+	require.JSONEq(`{"kind":"duplicate","name":"b","pattern":"a*","offset":-1,"other":"a","message":"same pattern as a"}`, string(data))
+}
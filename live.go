@@ -0,0 +1,203 @@
+package multiglob
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// Live holds a MultiGlob that can be updated while other goroutines are matching against it.
+// Every update produces a new immutable snapshot with its own version number, which is
+// published with an atomic pointer swap: readers never wait on writers, and always see
+// either the previous snapshot or the new one in its entirety.
+//
+// Live only forwards the most common lookups. Everything else is called on the MultiGlob
+// returned by Snapshot.
+type Live struct {
+	mu       sync.Mutex // Serializes writers
+	current  atomic.Pointer[version]
+	versions []*version // Retained versions, oldest first
+	limit    int
+	latest   uint64
+}
+
+type version struct {
+	number uint64
+	mg     *MultiGlob
+}
+
+// NewLive returns a Live serving mg as version 1. mg must not be modified afterwards.
+// history is the number of versions retained for Snapshot and Rollback, including the
+// current one. If it's less than 1, every version is retained.
+func NewLive(mg *MultiGlob, history int) *Live {
+	l := &Live{
+		limit:  history,
+		latest: 1,
+	}
+
+	v := &version{
+		number: 1,
+		mg:     mg,
+	}
+	l.versions = []*version{v}
+	l.current.Store(v)
+
+	return l
+}
+
+// Batch is a set of changes that's applied to a Live as a single version. The zero value
+// is an empty batch.
+type Batch struct {
+	ops []batchOp
+}
+
+type batchOp struct {
+	name    string
+	pattern string
+	remove  bool
+}
+
+// AddPattern adds the pattern to the batch. If a pattern with that name already exists,
+// it's replaced.
+func (b *Batch) AddPattern(name, pattern string) {
+	b.ops = append(b.ops, batchOp{
+		name:    name,
+		pattern: pattern,
+	})
+}
+
+// RemovePattern removes the named pattern in the batch.
+func (b *Batch) RemovePattern(name string) {
+	b.ops = append(b.ops, batchOp{
+		name:   name,
+		remove: true,
+	})
+}
+
+// Apply applies all the changes in the batch, in the order they were made, on top of the
+// current snapshot and publishes the result as a new version, which is returned. If any of
+// the changes fails, nothing is published.
+func (l *Live) Apply(b *Batch) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	mg := l.current.Load().mg.clone()
+	for _, op := range b.ops {
+		var err error
+		if op.remove {
+			err = mg.RemovePattern(op.name)
+		} else {
+			err = mg.ReplacePattern(op.name, op.pattern)
+		}
+
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to apply change to %s", op.name)
+		}
+	}
+
+	l.latest++
+	v := &version{
+		number: l.latest,
+		mg:     mg,
+	}
+
+	l.versions = append(l.versions, v)
+	if l.limit > 0 && len(l.versions) > l.limit {
+		l.versions = append(l.versions[:0:0], l.versions[len(l.versions)-l.limit:]...)
+	}
+
+	l.current.Store(v)
+	return v.number, nil
+}
+
+// Rollback publishes the snapshot with the given version again, making it the current
+// one. The next Apply builds on top of it, with a new version number.
+func (l *Live) Rollback(number uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	v := l.find(number)
+	if v == nil {
+		return errors.Errorf("version %d not found", number)
+	}
+
+	l.current.Store(v)
+	return nil
+}
+
+// Version returns the version number of the current snapshot.
+func (l *Live) Version() uint64 {
+	return l.current.Load().number
+}
+
+// Versions returns the numbers of all the retained versions, oldest first.
+func (l *Live) Versions() []uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	numbers := make([]uint64, 0, len(l.versions))
+	for _, v := range l.versions {
+		numbers = append(numbers, v.number)
+	}
+	return numbers
+}
+
+// Snapshot returns the current snapshot along with its version. Use it to make several
+// calls against the same set of patterns. The returned MultiGlob must not be modified.
+func (l *Live) Snapshot() (*MultiGlob, uint64) {
+	v := l.current.Load()
+	return v.mg, v.number
+}
+
+// SnapshotAt returns the snapshot with the given version, if it's still retained. The
+// returned MultiGlob must not be modified.
+func (l *Live) SnapshotAt(number uint64) (*MultiGlob, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	v := l.find(number)
+	if v == nil {
+		return nil, false
+	}
+	return v.mg, true
+}
+
+func (l *Live) find(number uint64) *version {
+	for _, v := range l.versions {
+		if v.number == number {
+			return v
+		}
+	}
+	return nil
+}
+
+// Match calls Match on the current snapshot.
+func (l *Live) Match(input string) bool {
+	return l.current.Load().mg.Match(input)
+}
+
+// FindAllPatterns calls FindAllPatterns on the current snapshot.
+func (l *Live) FindAllPatterns(input string) []string {
+	return l.current.Load().mg.FindAllPatterns(input)
+}
+
+// FindPattern calls FindPattern on the current snapshot.
+func (l *Live) FindPattern(input string) (string, bool) {
+	return l.current.Load().mg.FindPattern(input)
+}
+
+// FindAllGlobs calls FindAllGlobs on the current snapshot.
+func (l *Live) FindAllGlobs(input string) map[string][]string {
+	return l.current.Load().mg.FindAllGlobs(input)
+}
+
+// FindGlobs calls FindGlobs on the current snapshot.
+func (l *Live) FindGlobs(input string) (name string, globs []string, matched bool) {
+	return l.current.Load().mg.FindGlobs(input)
+}
+
+// FindGlobsForPattern calls FindGlobsForPattern on the current snapshot.
+func (l *Live) FindGlobsForPattern(input, name string) (globs []string, err error) {
+	return l.current.Load().mg.FindGlobsForPattern(input, name)
+}
@@ -0,0 +1,136 @@
+package multiglob
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestLiveApply(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("foo", "foo*")
+	l := NewLive(b.MustCompile(), 0)
+	require.Equal(uint64(1), l.Version())
+
+	batch := &Batch{}
+	batch.AddPattern("bar", "bar*")
+	batch.RemovePattern("foo")
+
+	v, err := l.Apply(batch)
+	require.NoError(err)
+	require.Equal(uint64(2), v)
+	require.Equal(uint64(2), l.Version())
+
+	require.False(l.Match("football"))
+	require.True(l.Match("barney"))
+
+	old, ok := l.SnapshotAt(1)
+	require.True(ok)
+	require.True(old.Match("football"))
+	require.False(old.Match("barney"))
+
+	require.Equal([]uint64{1, 2}, l.Versions())
+}
+
+func TestLiveApplyFailure(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("foo", "foo*")
+	l := NewLive(b.MustCompile(), 0)
+
+	batch := &Batch{}
+	batch.AddPattern("bar", "bar*")
+	batch.RemovePattern("missing")
+
+	_, err := l.Apply(batch)
+	require.Error(err)
+	require.Equal(uint64(1), l.Version())
+	require.False(l.Match("barney"))
+
+	batch = &Batch{}
+	batch.AddPattern("bar", "[bar")
+
+	_, err = l.Apply(batch)
+	require.Error(err)
+	require.Equal([]uint64{1}, l.Versions())
+}
+
+func TestLiveRollback(t *testing.T) {
+	require := r.New(t)
+
+	l := NewLive(New().MustCompile(), 3)
+	for i := 0; i < 4; i++ {
+		batch := &Batch{}
+		batch.AddPattern("p", fmt.Sprint(i))
+		_, err := l.Apply(batch)
+		require.NoError(err)
+	}
+
+	require.Equal([]uint64{3, 4, 5}, l.Versions())
+	require.True(l.Match("3"))
+
+	require.Error(l.Rollback(2))
+	require.NoError(l.Rollback(4))
+
+	mg, v := l.Snapshot()
+	require.Equal(uint64(4), v)
+	require.True(mg.Match("2"))
+	require.False(mg.Match("3"))
+
+	batch := &Batch{}
+	batch.AddPattern("q", "q")
+	v, err := l.Apply(batch)
+	require.NoError(err)
+	require.Equal(uint64(6), v)
+	require.True(l.Match("2"))
+	require.True(l.Match("q"))
+}
+
+func TestLiveConcurrent(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("a", "a*")
+	b.MustAddPattern("b", "b*")
+	b.MustAddPattern("c", "c1")
+	l := NewLive(b.MustCompile(), 0)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				// Every version contains exactly one of the two patterns for c.
+				mg, _ := l.Snapshot()
+				if mg.Match("c1") == mg.Match("c2") {
+					t.Error("inconsistent snapshot")
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		batch := &Batch{}
+		batch.RemovePattern("c")
+		batch.AddPattern("c", fmt.Sprintf("c%d", i%2+1))
+		_, err := l.Apply(batch)
+		require.NoError(err)
+	}
+
+	close(done)
+	wg.Wait()
+}
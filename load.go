@@ -0,0 +1,478 @@
+package multiglob
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// Format is a file format for sets of named patterns.
+type Format int
+
+const (
+	// FormatText is a line based format. Each line holds a pattern name and the pattern,
+	// separated by a tab. Blank lines, and lines starting with # are ignored.
+	FormatText Format = iota
+	// FormatJSON is a JSON object mapping pattern names to patterns. Under DuplicateKeepAll,
+	// a name can map to an array of patterns instead.
+	FormatJSON
+	// FormatYAML is a YAML map of pattern names to patterns. Under DuplicateKeepAll, a name
+	// can map to a list of patterns instead.
+	FormatYAML
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatText:
+		return "text"
+	case FormatJSON:
+		return "JSON"
+	case FormatYAML:
+		return "YAML"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// LoadError is returned when a pattern file, or a pattern in it, can't be loaded. It records
+// where in the file the problem is.
+type LoadError struct {
+	File string // Empty if the name of the file isn't known
+	Line int    // 1-based, or 0 if the problem isn't with a specific line
+	Name string // The name of the pattern the problem is with, if any
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	var b strings.Builder
+
+	switch {
+	case e.File != "" && e.Line > 0:
+		fmt.Fprintf(&b, "%s:%d: ", e.File, e.Line)
+	case e.File != "":
+		fmt.Fprintf(&b, "%s: ", e.File)
+	case e.Line > 0:
+		fmt.Fprintf(&b, "line %d: ", e.Line)
+	}
+
+	if e.Name != "" {
+		fmt.Fprintf(&b, "pattern %s: ", e.Name)
+	}
+
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// LoadErrors is returned when patterns are added in bulk, by Load or AddPatterns, and some
+// of them can't be added. It holds a *LoadError for each of those patterns.
+type LoadErrors []*LoadError
+
+func (e LoadErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d patterns failed to load:", len(e))
+	for _, err := range e {
+		b.WriteString("\n\t")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors in the list.
+func (e LoadErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+type entry struct {
+	name    string
+	pattern string
+	line    int
+}
+
+// Load reads named patterns in the given format from r and adds them to the builder. If r
+// has a Name method, like *os.File, the name is used in errors. If the file itself can't be
+// read, a *LoadError is returned. If some of the patterns in it can't be added, LoadErrors
+// is returned, and none of them are added unless the builder is lenient.
+func (m *Builder) Load(r io.Reader, format Format) error {
+	file := ""
+	if named, ok := r.(interface{ Name() string }); ok {
+		file = named.Name()
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return &LoadError{File: file, Err: err}
+	}
+
+	var entries []entry
+	switch format {
+	case FormatText:
+		entries, err = readText(data)
+	case FormatJSON:
+		entries, err = readJSON(data)
+	case FormatYAML:
+		entries, err = readYAML(data)
+	default:
+		err = &LoadError{Err: errors.Errorf("unknown format %s", format)}
+	}
+	if err != nil {
+		err.(*LoadError).File = file
+		return err
+	}
+
+	return m.addEntries(file, entries)
+}
+
+// addEntries parses and adds all the entries. Errors for every entry that can't be added
+// are returned in LoadErrors. Unless the builder is lenient, nothing is added if there are
+// any.
+func (m *Builder) addEntries(file string, entries []entry) error {
+	var (
+		errs   LoadErrors
+		parsed = make([]*parser.Node, len(entries))
+		seen   = make(map[string]bool, len(entries))
+	)
+
+	for i, e := range entries {
+		if m.duplicates == DuplicateError && (seen[e.name] || m.patterns[e.name] != nil) {
+			errs = append(errs, &LoadError{File: file, Line: e.line, Name: e.name, Err: ErrDuplicateName})
+			continue
+		}
+
+		var err error
+		if parsed[i], err = parse(e.name, e.pattern); err != nil {
+			errs = append(errs, &LoadError{File: file, Line: e.line, Name: e.name, Err: err})
+			continue
+		}
+		seen[e.name] = true
+	}
+
+	if len(errs) != 0 && !m.lenient {
+		return errs
+	}
+
+	for i, e := range entries {
+		if parsed[i] != nil {
+			m.add(e.name, e.pattern, parsed[i])
+		}
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// LoadFile loads the named patterns in the file at path. The format is picked based on the
+// extension: .json for FormatJSON, .yaml and .yml for FormatYAML and FormatText for
+// anything else. See Load.
+func (m *Builder) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to load patterns")
+	}
+	defer f.Close()
+
+	return m.Load(f, formatForPath(path))
+}
+
+func formatForPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatText
+	}
+}
+
+func readText(data []byte) ([]entry, error) {
+	var entries []entry
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(nil, len(data)+1)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSuffix(s.Text(), "\r")
+		if trimmed := strings.TrimSpace(text); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		name, pattern, ok := strings.Cut(text, "\t")
+		if !ok {
+			return nil, &LoadError{Line: line, Err: errors.New("missing tab between name and pattern")}
+		}
+
+		entries = append(entries, entry{
+			name:    strings.TrimSpace(name),
+			pattern: pattern,
+			line:    line,
+		})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, &LoadError{Err: err}
+	}
+	return entries, nil
+}
+
+func readJSON(data []byte) ([]entry, error) {
+	var (
+		entries []entry
+		dec     = json.NewDecoder(bytes.NewReader(data))
+	)
+
+	lineAt := func() int {
+		return bytes.Count(data[:dec.InputOffset()], []byte("\n")) + 1
+	}
+
+	if t, err := dec.Token(); err != nil {
+		return nil, &LoadError{Line: lineAt(), Err: err}
+	} else if t != json.Delim('{') {
+		return nil, &LoadError{Line: lineAt(), Err: errors.New("expected an object of patterns")}
+	}
+
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, &LoadError{Line: lineAt(), Err: err}
+		}
+		name := t.(string)
+		line := lineAt()
+
+		t, err = dec.Token()
+		if err != nil {
+			return nil, &LoadError{Line: lineAt(), Name: name, Err: err}
+		}
+
+		if t == json.Delim('[') {
+			for dec.More() {
+				if t, err = dec.Token(); err != nil {
+					return nil, &LoadError{Line: lineAt(), Name: name, Err: err}
+				}
+
+				pattern, ok := t.(string)
+				if !ok {
+					return nil, &LoadError{Line: lineAt(), Name: name, Err: errors.New("pattern must be a string")}
+				}
+
+				entries = append(entries, entry{
+					name:    name,
+					pattern: pattern,
+					line:    lineAt(),
+				})
+			}
+
+			// Consume the closing bracket
+			if _, err = dec.Token(); err != nil {
+				return nil, &LoadError{Line: lineAt(), Name: name, Err: err}
+			}
+			continue
+		}
+
+		pattern, ok := t.(string)
+		if !ok {
+			return nil, &LoadError{Line: line, Name: name, Err: errors.New("pattern must be a string or an array of strings")}
+		}
+
+		entries = append(entries, entry{
+			name:    name,
+			pattern: pattern,
+			line:    line,
+		})
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, &LoadError{Line: lineAt(), Err: err}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &LoadError{Line: lineAt(), Err: errors.New("unexpected data after patterns")}
+	}
+
+	return entries, nil
+}
+
+func readYAML(data []byte) ([]entry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &LoadError{Err: err}
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &LoadError{Line: root.Line, Err: errors.New("expected a map of patterns")}
+	}
+
+	entries := make([]entry, 0, len(root.Content)/2)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		switch value.Kind {
+		case yaml.ScalarNode:
+			entries = append(entries, entry{
+				name:    key.Value,
+				pattern: value.Value,
+				line:    key.Line,
+			})
+		case yaml.SequenceNode:
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, &LoadError{Line: item.Line, Name: key.Value, Err: errors.New("pattern must be a string")}
+				}
+
+				entries = append(entries, entry{
+					name:    key.Value,
+					pattern: item.Value,
+					line:    item.Line,
+				})
+			}
+		default:
+			return nil, &LoadError{Line: value.Line, Name: key.Value, Err: errors.New("pattern must be a string or a list of strings")}
+		}
+	}
+
+	return entries, nil
+}
+
+// Save writes the patterns in the builder to w in the given format, in the order they were
+// first added. The output can be read back with Load.
+func (m *Builder) Save(w io.Writer, format Format) error {
+	switch format {
+	case FormatText:
+		return m.writeText(w)
+	case FormatJSON:
+		return m.writeJSON(w)
+	case FormatYAML:
+		return m.writeYAML(w)
+	default:
+		return errors.Errorf("unknown format %s", format)
+	}
+}
+
+func (m *Builder) writeText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, name := range m.order {
+		trimmed := strings.TrimSpace(name)
+		if trimmed != name || name == "" || strings.HasPrefix(name, "#") || strings.ContainsAny(name, "\t\r\n") {
+			return errors.Errorf("pattern name %q can't be written in the text format", name)
+		}
+
+		for _, pattern := range m.sources[name] {
+			if strings.ContainsAny(pattern, "\r\n") {
+				return errors.Errorf("pattern %s can't be written in the text format", name)
+			}
+
+			bw.WriteString(name)
+			bw.WriteByte('\t')
+			bw.WriteString(pattern)
+			bw.WriteByte('\n')
+		}
+	}
+
+	return bw.Flush()
+}
+
+func (m *Builder) writeJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+
+	for i, name := range m.order {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		key, err := jsonString(name)
+		if err != nil {
+			return err
+		}
+
+		sources := m.sources[name]
+		values := make([]string, len(sources))
+		for j, pattern := range sources {
+			if values[j], err = jsonString(pattern); err != nil {
+				return err
+			}
+		}
+
+		if len(values) == 1 {
+			fmt.Fprintf(&buf, "\n  %s: %s", key, values[0])
+		} else {
+			fmt.Fprintf(&buf, "\n  %s: [%s]", key, strings.Join(values, ", "))
+		}
+	}
+
+	if len(m.order) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// jsonString returns s as a JSON string. Unlike json.Marshal, it doesn't escape HTML
+// characters, since they're common in patterns.
+func jsonString(s string) (string, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func (m *Builder) writeYAML(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range m.order {
+		var value *yaml.Node
+		if sources := m.sources[name]; len(sources) == 1 {
+			value = yamlString(sources[0])
+		} else {
+			value = &yaml.Node{Kind: yaml.SequenceNode}
+			for _, pattern := range sources {
+				value.Content = append(value.Content, yamlString(pattern))
+			}
+		}
+
+		root.Content = append(root.Content, yamlString(name), value)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func yamlString(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
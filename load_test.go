@@ -0,0 +1,272 @@
+package multiglob
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		format Format
+		input  string
+	}{
+		{
+			format: FormatText,
+			input: "# fruit\n" +
+				"foo\tfoo*\n" +
+				"\n" +
+				"  # indented comment\n" +
+				"bar baz\t*bar # not a comment\r\n" +
+				"esc\t\\[*\n",
+		},
+		{
+			format: FormatJSON,
+			input: `{
+  "foo": "foo*",
+  "bar baz": "*bar # not a comment",
+  "esc": "\\[*"
+}`,
+		},
+		{
+			format: FormatYAML,
+			input: `# fruit
+foo: foo*
+bar baz: "*bar # not a comment"
+esc: \[*
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format.String(), func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			require.NoError(b.Load(strings.NewReader(test.input), test.format))
+
+			require.Equal([]string{"foo", "bar baz", "esc"}, b.order)
+			require.Equal(map[string][]string{
+				"foo":     {"foo*"},
+				"bar baz": {"*bar # not a comment"},
+				"esc":     {`\[*`},
+			}, b.sources)
+
+			mg := b.MustCompile()
+			require.Equal([]string{"bar baz"}, mg.FindAllPatterns("a bar # not a comment"))
+			require.Equal([]string{"esc"}, mg.FindAllPatterns("[x"))
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		format Format
+		input  string
+		line   int
+		name   string
+	}{
+		{
+			format: FormatText,
+			input:  "foo\tfoo*\n# comment\nbar\t[bar\n",
+			line:   3,
+			name:   "bar",
+		},
+		{
+			format: FormatText,
+			input:  "foo\tfoo*\nbar *bar\n",
+			line:   2,
+		},
+		{
+			format: FormatJSON,
+			input:  "{\n  \"foo\": \"foo*\",\n  \"bar\": \"[bar\"\n}",
+			line:   3,
+			name:   "bar",
+		},
+		{
+			format: FormatJSON,
+			input:  "{\n  \"foo\": \"foo*\",\n  \"bar\": 12\n}",
+			line:   3,
+			name:   "bar",
+		},
+		{
+			format: FormatJSON,
+			input:  "[\"foo\"]",
+			line:   1,
+		},
+		{
+			format: FormatYAML,
+			input:  "foo: foo*\n\nbar: \"[bar\"\n",
+			line:   3,
+			name:   "bar",
+		},
+		{
+			format: FormatYAML,
+			input:  "foo: foo*\nbar:\n  baz: bar\n",
+			line:   3,
+			name:   "bar",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			err := b.Load(strings.NewReader(test.input), test.format)
+
+			var loadErr *LoadError
+			require.True(errors.As(err, &loadErr))
+			require.Equal(test.line, loadErr.Line)
+			require.Equal(test.name, loadErr.Name)
+			require.Empty(b.patterns)
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	require := r.New(t)
+
+	path := filepath.Join(t.TempDir(), "patterns.yml")
+	require.NoError(os.WriteFile(path, []byte("foo: foo*\nbar: \"[bar\"\n"), 0o600))
+
+	err := New().LoadFile(path)
+	require.EqualError(err, path+`:2: pattern bar: failed to parse [bar: unclosed range missing ]`)
+
+	require.Error(New().LoadFile(filepath.Join(t.TempDir(), "missing.txt")))
+}
+
+func TestSave(t *testing.T) {
+	for _, format := range []Format{FormatText, FormatJSON, FormatYAML} {
+		t.Run(format.String(), func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			b.MustAddPattern("zebra", "*stripes*")
+			b.MustAddPattern("apple", "<red> & [a-z]+")
+			b.MustAddPattern("quote", `"*"`)
+			b.MustAddPattern("zebra", "zebra*")
+
+			var buf bytes.Buffer
+			require.NoError(b.Save(&buf, format))
+
+			loaded := New()
+			require.NoError(loaded.Load(&buf, format))
+			require.Equal(b.order, loaded.order)
+			require.Equal(b.sources, loaded.sources)
+			require.Equal(b.patterns, loaded.patterns)
+		})
+	}
+}
+
+func TestSaveFormats(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("foo", "foo*")
+	b.MustAddPattern("bar", "<*>")
+
+	var buf bytes.Buffer
+	require.NoError(b.Save(&buf, FormatText))
+	require.Equal("foo\tfoo*\nbar\t<*>\n", buf.String())
+
+	buf.Reset()
+	require.NoError(b.Save(&buf, FormatJSON))
+	require.Equal("{\n  \"foo\": \"foo*\",\n  \"bar\": \"<*>\"\n}\n", buf.String())
+
+	buf.Reset()
+	require.NoError(New().Save(&buf, FormatJSON))
+	require.Equal("{}\n", buf.String())
+
+	b = New()
+	b.MustAddPattern("#tag", "foo")
+	require.Error(b.Save(&buf, FormatText))
+}
+
+func TestLoadDuplicates(t *testing.T) {
+	tests := []struct {
+		format Format
+		input  string
+		line   int
+	}{
+		{
+			format: FormatText,
+			input:  "a\tfoo*\nb\tbar*\na\t*ney\n",
+			line:   3,
+		},
+		{
+			format: FormatJSON,
+			input:  "{\n  \"a\": \"foo*\",\n  \"b\": \"bar*\",\n  \"a\": \"*ney\"\n}",
+			line:   4,
+		},
+		{
+			format: FormatJSON,
+			input:  "{\n  \"a\": [\"foo*\",\n    \"*ney\"],\n  \"b\": \"bar*\"\n}",
+			line:   3,
+		},
+		{
+			format: FormatYAML,
+			input:  "a:\n  - foo*\n  - \"*ney\"\nb: bar*\n",
+			line:   3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			b.SetDuplicatePolicy(DuplicateKeepAll)
+			require.NoError(b.Load(strings.NewReader(test.input), test.format))
+			require.Equal([]string{"foo*", "*ney"}, b.sources["a"])
+			require.Equal([]string{"bar*"}, b.sources["b"])
+
+			var buf bytes.Buffer
+			require.NoError(b.Save(&buf, test.format))
+
+			loaded := New()
+			loaded.SetDuplicatePolicy(DuplicateKeepAll)
+			require.NoError(loaded.Load(&buf, test.format))
+			require.Equal(b.sources, loaded.sources)
+
+			b = New()
+			b.SetDuplicatePolicy(DuplicateError)
+			err := b.Load(strings.NewReader(test.input), test.format)
+			require.ErrorIs(err, ErrDuplicateName)
+
+			var loadErr *LoadError
+			require.True(errors.As(err, &loadErr))
+			require.Equal(test.line, loadErr.Line)
+			require.Equal("a", loadErr.Name)
+		})
+	}
+}
+
+func TestLoadAllErrors(t *testing.T) {
+	require := r.New(t)
+
+	input := "a\tfoo*\nb\t[b\nc\t*c\nd\t\\d\n"
+
+	b := New()
+	err := b.Load(strings.NewReader(input), FormatText)
+
+	var errs LoadErrors
+	require.ErrorAs(err, &errs)
+	require.Len(errs, 2)
+	require.Equal(2, errs[0].Line)
+	require.Equal(4, errs[1].Line)
+	require.Equal("2 patterns failed to load:\n"+
+		"\tline 2: pattern b: failed to parse [b: unclosed range missing ]\n"+
+		"\tline 4: pattern d: failed to parse \\d: unknown escaping: \\d", err.Error())
+	require.Empty(b.patterns)
+
+	b = New()
+	b.SetLenient(true)
+	require.ErrorAs(b.Load(strings.NewReader(input), FormatText), &errs)
+	require.Equal([]string{"a", "c"}, b.order)
+}
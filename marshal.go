@@ -0,0 +1,253 @@
+package multiglob
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// The binary format is a header, the payload and a checksum:
+//
+//	magic    [4]byte  "MGLB"
+//	version  uint16   binaryVersion
+//	length   uint64   length of the payload
+//	payload  []byte   the merged tree, followed by the number of pattern names and, sorted
+//	                  by name, each name with the number of patterns under it and their
+//	                  trees, then the number of templates and, sorted by name, each
+//	                  name with its template, and then the separator, or 0 if there's none
+//	checksum uint32   CRC-32 (IEEE) of the payload
+//
+// All integers in the header and checksum are big endian.
+const (
+	binaryMagic   = "MGLB"
+	binaryVersion = 4
+
+	headerLen   = len(binaryMagic) + 2 + 8
+	checksumLen = 4
+)
+
+// MarshalBinary encodes the compiled MultiGlob, so that it can be loaded later with
+// UnmarshalBinary instead of being compiled again. It implements encoding.BinaryMarshaler.
+func (mg *MultiGlob) MarshalBinary() ([]byte, error) {
+	payload := parser.AppendNode(nil, mg.node)
+
+	names := make([]string, 0, len(mg.patterns))
+	for name := range mg.patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	payload = binary.AppendUvarint(payload, uint64(len(names)))
+	for _, name := range names {
+		payload = binary.AppendUvarint(payload, uint64(len(name)))
+		payload = append(payload, name...)
+
+		payload = binary.AppendUvarint(payload, uint64(len(mg.patterns[name])))
+		for _, p := range mg.patterns[name] {
+			payload = parser.AppendNode(payload, p)
+		}
+	}
+
+	names = names[:0]
+	for name := range mg.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	payload = binary.AppendUvarint(payload, uint64(len(names)))
+	for _, name := range names {
+		payload = appendString(payload, name)
+		payload = appendString(payload, mg.templates[name].source)
+	}
+
+	payload = binary.AppendUvarint(payload, uint64(mg.separator))
+
+	data := make([]byte, 0, headerLen+len(payload)+checksumLen)
+	data = append(data, binaryMagic...)
+	data = binary.BigEndian.AppendUint16(data, binaryVersion)
+	data = binary.BigEndian.AppendUint64(data, uint64(len(payload)))
+	data = append(data, payload...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(payload))
+
+	return data, nil
+}
+
+// UnmarshalBinary replaces the contents of mg with a MultiGlob encoded by MarshalBinary.
+// It implements encoding.BinaryUnmarshaler.
+func (mg *MultiGlob) UnmarshalBinary(data []byte) error {
+	length, err := readHeader(data)
+	if err != nil {
+		return err
+	}
+
+	data = data[headerLen:]
+	if uint64(len(data)) != length+checksumLen {
+		return errors.New("invalid data length")
+	}
+
+	return mg.decodePayload(data[:length], data[length:])
+}
+
+// WriteTo writes the binary encoding of mg to w. It implements io.WriterTo.
+func (mg *MultiGlob) WriteTo(w io.Writer) (int64, error) {
+	data, err := mg.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadFrom replaces the contents of mg with a MultiGlob read from r, as written by WriteTo.
+// It stops reading at the end of the encoded MultiGlob. It implements io.ReaderFrom.
+func (mg *MultiGlob) ReadFrom(r io.Reader) (int64, error) {
+	header := make([]byte, headerLen)
+	n, err := io.ReadFull(r, header)
+	read := int64(n)
+	if err != nil {
+		return read, errors.Wrap(err, "failed to read header")
+	}
+
+	length, err := readHeader(header)
+	if err != nil {
+		return read, err
+	}
+
+	// Copy into a growing buffer rather than allocating length bytes up front, so a
+	// corrupt length can't cause a huge allocation.
+	var buf bytes.Buffer
+	copied, err := io.CopyN(&buf, r, int64(length)+checksumLen)
+	read += copied
+	if err != nil {
+		return read, errors.Wrap(err, "failed to read payload")
+	}
+
+	data := buf.Bytes()
+	return read, mg.decodePayload(data[:length], data[length:])
+}
+
+func readHeader(data []byte) (uint64, error) {
+	if len(data) < headerLen || string(data[:len(binaryMagic)]) != binaryMagic {
+		return 0, errors.New("not an encoded MultiGlob")
+	}
+
+	if v := binary.BigEndian.Uint16(data[len(binaryMagic):]); v != binaryVersion {
+		return 0, errors.Errorf("unsupported format version %d", v)
+	}
+
+	length := binary.BigEndian.Uint64(data[len(binaryMagic)+2:])
+	if length > uint64(1<<62) {
+		return 0, errors.New("invalid data length")
+	}
+	return length, nil
+}
+
+func (mg *MultiGlob) decodePayload(payload, checksum []byte) error {
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(checksum) {
+		return errors.New("checksum mismatch")
+	}
+
+	node, payload, err := parser.ReadNode(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode tree")
+	}
+
+	count, size := binary.Uvarint(payload)
+	if size <= 0 || count > uint64(len(payload)) {
+		return errors.New("failed to decode pattern count")
+	}
+	payload = payload[size:]
+
+	patterns := make(map[string][]*parser.Node, count)
+	for i := uint64(0); i < count; i++ {
+		length, size := binary.Uvarint(payload)
+		if size <= 0 || length > uint64(len(payload)-size) {
+			return errors.New("failed to decode pattern name")
+		}
+		name := string(payload[size : size+int(length)])
+		payload = payload[size+int(length):]
+
+		asts, size := binary.Uvarint(payload)
+		if size <= 0 || asts == 0 || asts > uint64(len(payload)-size) {
+			return errors.Errorf("failed to decode pattern count of %s", name)
+		}
+		payload = payload[size:]
+
+		for j := uint64(0); j < asts; j++ {
+			var p *parser.Node
+			p, payload, err = parser.ReadNode(payload)
+			if err != nil {
+				return errors.Wrapf(err, "failed to decode pattern %s", name)
+			}
+			patterns[name] = append(patterns[name], p)
+		}
+	}
+
+	count, size = binary.Uvarint(payload)
+	if size <= 0 || count > uint64(len(payload)) {
+		return errors.New("failed to decode template count")
+	}
+	payload = payload[size:]
+
+	templates := make(map[string]*template, count)
+	for i := uint64(0); i < count; i++ {
+		var name, source string
+		if name, payload, err = readString(payload); err != nil {
+			return errors.Wrap(err, "failed to decode template name")
+		}
+		if source, payload, err = readString(payload); err != nil {
+			return errors.Wrapf(err, "failed to decode template of %s", name)
+		}
+		if patterns[name] == nil {
+			return errors.Errorf("failed to decode template of %s: pattern not found", name)
+		}
+
+		if templates[name], err = parseTemplate(source); err != nil {
+			return errors.Wrapf(err, "failed to decode template of %s", name)
+		}
+	}
+
+	sep, size := binary.Uvarint(payload)
+	if size <= 0 || sep > utf8.MaxRune {
+		return errors.New("failed to decode separator")
+	}
+	payload = payload[size:]
+
+	if len(payload) != 0 {
+		return errors.New("unexpected data after separator")
+	}
+
+	mg.node = node
+	mg.patterns = patterns
+	mg.templates = templates
+	mg.separator = rune(sep)
+	mg.segments = nil
+	if mg.separator != 0 {
+		// The segments are merged again rather than stored, since they only repeat the patterns
+		mg.segments = segmentTree(patterns, mg.separator)
+	}
+	return nil
+}
+
+func appendString(data []byte, s string) []byte {
+	data = binary.AppendUvarint(data, uint64(len(s)))
+	return append(data, s...)
+}
+
+// readString reads a string written by appendString, and returns it along with the rest of
+// the data.
+func readString(data []byte) (string, []byte, error) {
+	length, size := binary.Uvarint(data)
+	if size <= 0 || length > uint64(len(data)-size) {
+		return "", nil, errors.New("invalid string length")
+	}
+	return string(data[size : size+int(length)]), data[size+int(length):], nil
+}
@@ -0,0 +1,84 @@
+package multiglob
+
+import (
+	"bytes"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.SetDuplicatePolicy(DuplicateKeepAll)
+	b.MustAddPattern("foo", "foo*")
+	b.MustAddPattern("foo", "*foo")
+	b.MustAddPattern("bar", "*bar")
+	b.MustAddPattern("range", "[a-c]+[^x]")
+	b.MustAddPattern("escape", `\[*\]`)
+
+	mg := b.MustCompile()
+	data, err := mg.MarshalBinary()
+	require.NoError(err)
+
+	var output MultiGlob
+	require.NoError(output.UnmarshalBinary(data))
+	require.Equal(mg, &output)
+
+	require.True(output.Match("football"))
+	require.True(output.Match("abz"))
+	require.Equal([]string{"re"}, output.FindAllGlobs("rebar")["bar"])
+
+	again, err := output.MarshalBinary()
+	require.NoError(err)
+	require.Equal(data, again)
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("foo", "foo*")
+	b.MustAddPattern("bar", "*bar")
+	mg := b.MustCompile()
+
+	var buf bytes.Buffer
+	written, err := mg.WriteTo(&buf)
+	require.NoError(err)
+	buf.WriteString("trailing")
+
+	var output MultiGlob
+	read, err := output.ReadFrom(&buf)
+	require.NoError(err)
+	require.Equal(written, read)
+	require.Equal(mg, &output)
+	require.Equal("trailing", buf.String())
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("foo", "foo*")
+	data, err := b.MustCompile().MarshalBinary()
+	require.NoError(err)
+
+	var mg MultiGlob
+	require.Error(mg.UnmarshalBinary(nil))
+	require.Error(mg.UnmarshalBinary([]byte("not a multiglob")))
+	require.Error(mg.UnmarshalBinary(data[:len(data)-1]))
+
+	corrupt := bytes.Clone(data)
+	corrupt[headerLen+3] ^= 0xff
+	require.ErrorContains(mg.UnmarshalBinary(corrupt), "checksum")
+
+	version := bytes.Clone(data)
+	version[5] = 99
+	require.ErrorContains(mg.UnmarshalBinary(version), "version")
+
+	_, err = mg.ReadFrom(bytes.NewReader(data[:len(data)-2]))
+	require.Error(err)
+
+	require.Nil(mg.node)
+}
@@ -1,7 +1,10 @@
 package multiglob
 
 import (
+	"slices"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"unicode/utf8"
 
 	"github.com/pkg/errors"
@@ -9,28 +12,108 @@ import (
 	"github.com/szabado/multiglob/internal/parser"
 )
 
+// DuplicatePolicy decides what AddPattern does when a pattern name is reused.
+type DuplicatePolicy int
+
+const (
+	// DuplicateLastWins replaces the pattern previously stored under the name. This is
+	// the default.
+	DuplicateLastWins DuplicatePolicy = iota
+	// DuplicateError makes AddPattern return ErrDuplicateName.
+	DuplicateError
+	// DuplicateKeepAll keeps every pattern added under the name. The name matches an input
+	// if any of its patterns do.
+	DuplicateKeepAll
+)
+
+// ErrDuplicateName is returned when a pattern name is reused under DuplicateError.
+var ErrDuplicateName = errors.New("duplicate pattern name")
+
 // Builder builds a MultiGlob.
 type Builder struct {
-	patterns map[string]*parser.Node
+	patterns   map[string][]*parser.Node
+	sources    map[string][]string
+	order      []string // Pattern names, in the order they were first added
+	templates  map[string]*template
+	separator  rune // Splits patterns into segments, if it's set
+	duplicates DuplicatePolicy
+	lenient    bool
 }
 
 // New returns a new Builder that can be used to create a MultiGlob.
 func New() *Builder {
 	return &Builder{
-		patterns: make(map[string]*parser.Node),
+		patterns:  make(map[string][]*parser.Node),
+		sources:   make(map[string][]string),
+		templates: make(map[string]*template),
 	}
 }
 
-// AddPattern adds the provided pattern to the builder and parses it.
+// SetDuplicatePolicy sets how patterns that reuse a name are handled from now on. Patterns
+// that were already added are left as they are.
+func (m *Builder) SetDuplicatePolicy(policy DuplicatePolicy) {
+	m.duplicates = policy
+}
+
+// SetLenient sets whether patterns that are added in bulk, by AddPatterns or Load, are
+// added even if some of the others can't be. By default, none of them are added.
+func (m *Builder) SetLenient(lenient bool) {
+	m.lenient = lenient
+}
+
+// AddPattern adds the provided pattern to the builder and parses it. If the name is already
+// in use, the builder's DuplicatePolicy decides what happens.
 func (m *Builder) AddPattern(name, pattern string) error {
-	p, err := parser.Parse(name, pattern)
+	if m.duplicates == DuplicateError && m.patterns[name] != nil {
+		return errors.Wrapf(ErrDuplicateName, "failed to add pattern %s", name)
+	}
+
+	p, err := parse(name, pattern)
 	if err != nil {
 		return errors.Wrap(err, "failed to add pattern")
 	}
-	m.patterns[name] = p
+
+	m.add(name, pattern, p)
 	return err
 }
 
+// AddPatterns adds all the provided patterns, which map names to patterns, to the builder.
+// Every pattern is parsed, even after one of them fails, so that the returned LoadErrors
+// reports all the problems at once, sorted by name. Unless the builder is lenient, none of
+// the patterns are added if any of them fail.
+func (m *Builder) AddPatterns(patterns map[string]string) error {
+	entries := make([]entry, 0, len(patterns))
+	for name, pattern := range patterns {
+		entries = append(entries, entry{
+			name:    name,
+			pattern: pattern,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	return m.addEntries("", entries)
+}
+
+// add stores an already parsed pattern. Callers have to check for duplicates under
+// DuplicateError.
+func (m *Builder) add(name, pattern string, p *parser.Node) {
+	existing, ok := m.patterns[name]
+	if !ok {
+		m.order = append(m.order, name)
+	}
+
+	if ok && m.duplicates == DuplicateKeepAll {
+		m.patterns[name] = append(existing, p)
+		m.sources[name] = append(m.sources[name], pattern)
+	} else {
+		m.patterns[name] = []*parser.Node{p}
+		m.sources[name] = []string{pattern}
+	}
+}
+
 // MustAddPattern wraps AddPattern, and panics if there is an error.
 func (m *Builder) MustAddPattern(name, pattern string) {
 	err := m.AddPattern(name, pattern)
@@ -41,24 +124,42 @@ func (m *Builder) MustAddPattern(name, pattern string) {
 
 // Compile merges all the compiled patterns into one MultiGlob and returns it.
 func (m *Builder) Compile() (*MultiGlob, error) {
-	var final *parser.Node
-	for _, p := range m.patterns {
-		if final == nil {
-			final = p
-		} else {
-			final = parser.Merge(final, p)
-		}
+	final := m.tree()
+	patterns := make(map[string][]*parser.Node, len(m.patterns))
+	for name, asts := range m.patterns {
+		patterns[name] = append([]*parser.Node(nil), asts...)
 	}
 
-	patterns := make(map[string]*parser.Node)
-	for k, v := range m.patterns {
-		patterns[k] = v
+	if err := checkTemplates(m.templates, patterns); err != nil {
+		return nil, errors.Wrap(err, "failed to compile")
 	}
 
-	return &MultiGlob{
-		node:     final,
-		patterns: patterns,
-	}, nil
+	templates := make(map[string]*template, len(m.templates))
+	for name, t := range m.templates {
+		templates[name] = t
+	}
+
+	mg := &MultiGlob{
+		node:      final,
+		patterns:  patterns,
+		templates: templates,
+		separator: m.separator,
+	}
+	if m.separator != 0 {
+		mg.segments = segmentTree(patterns, m.separator)
+	}
+	return mg, nil
+}
+
+// tree merges the patterns added to the builder.
+func (m *Builder) tree() *parser.Node {
+	final := &parser.Node{Type: parser.TypeRoot}
+	for _, asts := range m.patterns {
+		for _, p := range asts {
+			final = parser.Merge(final, p)
+		}
+	}
+	return final
 }
 
 // MustCompile wraps Compile, and panics if there is an error.
@@ -72,8 +173,15 @@ func (m *Builder) MustCompile() *MultiGlob {
 
 // MultiGlob is a matcher that is built from a collection of patterns. See Builder.
 type MultiGlob struct {
-	node     *parser.Node
-	patterns map[string]*parser.Node
+	node      *parser.Node
+	patterns  map[string][]*parser.Node
+	templates map[string]*template // Set with Builder.SetTemplate
+
+	// The patterns split into segments, for MatchSegments, if a separator was set
+	separator rune
+	segments  *parser.Node
+
+	scanCache atomic.Pointer[scannerCache] // For FindAllIn, built when it's first called
 }
 
 // Match determines if any pattern matches the provided string.
@@ -85,18 +193,7 @@ func (mg *MultiGlob) Match(input string) bool {
 // FindAllPatterns returns a list containing all patterns that matched this input.
 func (mg *MultiGlob) FindAllPatterns(input string) []string {
 	results, _ := match(mg.node, input, true)
-	duplicates := make(map[string]bool)
-
-	cleaned := make([]string, 0, len(results))
-	for _, result := range results {
-		if duplicates[result] {
-			continue
-		}
-		duplicates[result] = true
-		cleaned = append(cleaned, result)
-	}
-
-	return cleaned
+	return dedupe(results)
 }
 
 // FindPattern returns one pattern out of the set of patterns that matches input.
@@ -118,7 +215,7 @@ func (mg *MultiGlob) FindAllGlobs(input string) map[string][]string {
 
 	globs := make(map[string][]string)
 	for _, name := range patternNames {
-		g, _ := extractGlobs(input, mg.patterns[name])
+		g, _, _ := extractGlobsForName(input, mg.patterns[name], CaptureGreedy)
 		globs[name] = g
 	}
 
@@ -127,7 +224,7 @@ func (mg *MultiGlob) FindAllGlobs(input string) map[string][]string {
 
 // FindGlobs finds a matching pattern using FindPattern, and then extracts the globs
 // from the input based on that pattern. It also returns the name of the pattern
-// matched. This uses a greedy matching algorithm. For example:
+// matched. This uses a greedy matching algorithm, see FindGlobsWith for others. For example:
 //
 //   Input:         "test"
 //   Pattern Found: "t*t"
@@ -137,188 +234,157 @@ func (mg *MultiGlob) FindAllGlobs(input string) map[string][]string {
 //   Pattern Found: "*apple*"
 //   Globs:         ["pen pineapple ", " pen"]
 func (mg *MultiGlob) FindGlobs(input string) (name string, globs []string, matched bool) {
-	name, ok := mg.FindPattern(input)
+	return mg.FindGlobsWith(input, CaptureGreedy)
+}
+
+// FindGlobsForPattern extracts the globs from input using the named pattern.
+func (mg *MultiGlob) FindGlobsForPattern(input, name string) (globs []string, err error) {
+	globs, _, err = mg.FindGlobsForPatternIndex(input, name)
+	return globs, err
+}
+
+// FindGlobsForPatternIndex is like FindGlobsForPattern, but also returns which of the
+// patterns stored under name produced the globs. It's the index of the pattern in the order
+// they were added, which is only ever non-zero under DuplicateKeepAll. If more than one of
+// the patterns matches, the first one is used.
+func (mg *MultiGlob) FindGlobsForPatternIndex(input, name string) (globs []string, index int, err error) {
+	asts, ok := mg.patterns[name]
 	if !ok {
-		return "", nil, false
+		return nil, 0, errors.New("pattern not found")
 	}
 
-	globs, _ = extractGlobs(input, mg.patterns[name])
-	return name, globs, true
+	globs, index, err = extractGlobsForName(input, asts, CaptureGreedy)
+	if err != nil {
+		return nil, 0, errors.New("pattern did not match input")
+	}
+	return globs, index, nil
 }
 
-// FindGlobsForPattern extracts the globs from input using the named pattern.
-func (mg *MultiGlob) FindGlobsForPattern(input, name string) (globs []string, err error) {
-	if ast, ok := mg.patterns[name]; !ok {
-		return nil, errors.New("pattern not found")
-	} else {
-		globs, err := extractGlobs(input, ast)
-		if err != nil {
-			return nil, errors.New("pattern did not match input")
+// extractGlobsForName extracts the globs using the first of the patterns stored under a name
+// that matches the input, and returns its index.
+func extractGlobsForName(input string, asts []*parser.Node, strategy CaptureStrategy) ([]string, int, error) {
+	for i, ast := range asts {
+		if globs, err := extractGlobs(input, ast, strategy); err == nil {
+			return globs, i, nil
 		}
-		return globs, nil
 	}
+	return nil, 0, errTextNotFound
 }
 
 var errTextNotFound = errors.New("text not found")
 
 // extractGlobs returns the globs based on the pattern. It either returns a nil error or
 // errTextNotFound
-func extractGlobs(input string, ast *parser.Node) ([]string, error) {
-	var globs []string
-
-	for leafConsumed := false; !leafConsumed && ast != nil; {
-		switch ast.Type {
-		case parser.TypeText:
-			if !strings.HasPrefix(input, ast.Value) {
-				return nil, errTextNotFound
-			}
-			input = trimString(input, len(ast.Value))
-			if ast.Leaf {
-				leafConsumed = true
-			}
-		case parser.TypeAny:
-			// It's globbing time, baby!
-			if ast.Leaf {
-				globs = append(globs, input)
-				leafConsumed = true
-				break
-			} else if input == "" {
-				return nil, errTextNotFound
-			}
-
-			// Consume as much as possible, and then slowly consume less until we find a
-			// match or can't consume any less
-			for globbed := input; globbed != ""; {
-				child := ast.Children[0]
-
-				globEnds := child.LastIndex(globbed)
-				if globEnds < 0 {
-					return nil, errTextNotFound
-				}
-
-				globbed = globbed[:globEnds]
-
-				subglobs, err := extractGlobs(input[globEnds:], child)
-				if err != nil {
-					continue
-				}
-
-				// we found our match!
-				globs = append(globs, globbed)
-				globs = append(globs, subglobs...)
-				leafConsumed = true
-				break
-			}
-		case parser.TypeRange:
-			lastIndex := -1
-			for i, r := range input {
-				if !ast.Range.Matches(r) {
-					break
-				}
-				lastIndex = i
-				if !ast.Range.Repeated {
-					break
-				}
-			}
-
-			if lastIndex < 0 {
-				return nil, errTextNotFound
-			}
-
-			lastIndex++
+func extractGlobs(input string, ast *parser.Node, strategy CaptureStrategy) ([]string, error) {
+	spans, err := extractSpans(input, ast, strategy)
+	if err != nil {
+		return nil, err
+	}
 
-			if ast.Leaf {
-				if lastIndex == len(input) {
-					globs = append(globs, input)
-					leafConsumed = true
-					break
-				} else {
-					return nil, errTextNotFound
-				}
-			}
+	var globs []string
+	for _, span := range spans {
+		if !span.Literal {
+			globs = append(globs, input[span.Start:span.End])
+		}
+	}
+	return globs, nil
+}
 
-			for globbed := input[:lastIndex]; globbed != ""; {
-				r, size := utf8.DecodeLastRuneInString(globbed)
-				if r == utf8.RuneError && size < 2 {
-					return nil, errTextNotFound
-				}
+// extractSpans returns the spans of the input that each part of the pattern matched, picking
+// the decomposition the strategy prefers. It either returns a nil error or errTextNotFound
+func extractSpans(input string, ast *parser.Node, strategy CaptureStrategy) ([]Span, error) {
+	var (
+		spans []Span
+		found bool
+	)
+	// The search stops at the first decomposition, so nothing overwrites its spans
+	decompose(input, 0, ast, strategy == CaptureLazy, make([]Span, 0, 8), func(s []Span) bool {
+		spans, found = s, true
+		return false
+	})
+
+	if !found {
+		return nil, errTextNotFound
+	}
+	return spans, nil
+}
 
-				globEnds := len(globbed) - size + 1
-				// we've found a match. Recurse on the tail of input
-				subglobs, err := extractGlobs(input[globEnds:], ast.Children[0])
-				if err != nil {
-					// Shrink what is globbed by one
-					globbed = globbed[:globEnds-1]
-					continue
-				}
+func match(node *parser.Node, input string, exhaustive bool) ([]string, bool) {
+	return trace(node, input, exhaustive, nil)
+}
 
-				// We've found our match!
-				globs = append(globs, globbed)
-				globs = append(globs, subglobs...)
-				leafConsumed = true
-				break
-			}
+// trace is match, recording the walk in t if it's set.
+func trace(node *parser.Node, input string, exhaustive bool, t *tracer) ([]string, bool) {
+	var results []string
+	visit(node, input, t, func(names []string) bool {
+		if !exhaustive {
+			results = names
+			return false
 		}
+		results = append(results, names...)
+		return true
+	})
+	return results, len(results) != 0
+}
 
-		if !ast.Leaf {
-			ast = ast.Children[0]
-		}
+// visit is matchNode, recording the walk in t if it's set.
+func visit(node *parser.Node, input string, t *tracer, yield func(names []string) bool) bool {
+	if t == nil {
+		return matchNode(node, input, nil, yield)
 	}
 
-	return globs, nil
+	var results []string
+	step := t.enter(node, input)
+	ok := matchNode(node, input, t, func(names []string) bool {
+		results = append(results, names...)
+		return yield(names)
+	})
+	t.exit(step, node, input, results)
+	return ok
 }
 
-func match(node *parser.Node, input string, exhaustive bool) ([]string, bool) {
-	var (
-		results []string
-	)
-
+// matchNode calls yield with the names of each leaf below node that the input reaches, until
+// yield returns false, and returns false if it did. A name can come out several times.
+func matchNode(node *parser.Node, input string, t *tracer, yield func(names []string) bool) bool {
 	switch node.Type {
 	case parser.TypeAny:
-		if node.Leaf {
-			if !exhaustive {
-				return node.Name, true
-			}
-			results = merge(results, node.Name)
+		if node.Leaf && !yield(node.Name) {
+			return false
 		}
 
 		for _, child := range node.Children {
-			tempInput := input
-			for i := child.Index(tempInput); i >= 0; i = child.Index(tempInput) {
-				names, ok := match(child, trimString(tempInput, i), exhaustive)
-				tempInput = trimString(tempInput, i+len(child.Value))
-
-				if !ok {
-					continue
+			// Try the child at every offset it could start at, one rune after the other,
+			// since its matches can overlap
+			for offset := 0; offset <= len(input); {
+				i := child.Index(input[offset:])
+				if i < 0 {
+					break
 				}
+				start := offset + i
 
-				if !exhaustive {
-					return names, true
+				_, size := utf8.DecodeRuneInString(input[start:])
+				offset = start + max(size, 1)
+
+				if !visit(child, input[start:], t, yield) {
+					return false
 				}
-				results = merge(results, names)
 			}
 		}
 	case parser.TypeText:
 		if node.Leaf && node.Value == input {
-			if !exhaustive {
-				return node.Name, true
+			if !yield(node.Name) {
+				return false
 			}
-			results = merge(results, node.Name)
 		} else if !strings.HasPrefix(input, node.Value) {
-			return nil, false
+			return true
 		}
 
 		input = trimString(input, len(node.Value))
 
 		for _, c := range node.Children {
-			names, ok := match(c, input, exhaustive)
-			if !ok {
-				continue
-			}
-			if !exhaustive {
-				return names, true
+			if !visit(c, input, t, yield) {
+				return false
 			}
-			results = merge(results, names)
 		}
 
 	case parser.TypeRange:
@@ -331,15 +397,9 @@ func match(node *parser.Node, input string, exhaustive bool) ([]string, bool) {
 			short = strings.TrimPrefix(short, string(r))
 
 			for _, child := range node.Children {
-				names, ok := match(child, short, exhaustive)
-				if !ok {
-					continue
-				}
-
-				if !exhaustive {
-					return names, true
+				if !visit(child, short, t, yield) {
+					return false
 				}
-				results = merge(results, names)
 			}
 
 			if !node.Range.Repeated {
@@ -348,22 +408,17 @@ func match(node *parser.Node, input string, exhaustive bool) ([]string, bool) {
 		}
 
 		if node.Leaf && short == "" && len(short) != len(input) {
-			results = append(results, node.Name...)
+			return yield(node.Name)
 		}
 	case parser.TypeRoot:
 		for _, c := range node.Children {
-			names, ok := match(c, input, exhaustive)
-			if !ok {
-				continue
-			}
-			if !exhaustive {
-				return names, true
+			if !visit(c, input, t, yield) {
+				return false
 			}
-			results = merge(results, names)
 		}
 	}
 
-	return results, len(results) != 0
+	return true
 }
 
 func trimString(s string, prefixLen int) string {
@@ -373,12 +428,14 @@ func trimString(s string, prefixLen int) string {
 	return s[prefixLen:]
 }
 
+// merge returns the names in both lists. Either list can be the names of a node, so they're
+// copied into a new slice rather than appended to, which would write to the tree.
 func merge(sl1, sl2 []string) []string {
 	if sl2 == nil {
 		return sl1
 	} else if sl1 == nil {
 		return sl2
 	} else {
-		return append(sl1, sl2...)
+		return slices.Concat(sl1, sl2)
 	}
 }
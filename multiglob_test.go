@@ -201,6 +201,28 @@ func TestMatch(t *testing.T) {
 			},
 			output: false,
 		},
+		{
+			// Matches of the text after a * can overlap
+			input: "aaa",
+			patterns: []string{
+				"*aa",
+			},
+			output: true,
+		},
+		{
+			input: "xad",
+			patterns: []string{
+				"*[ab]c",
+			},
+			output: false,
+		},
+		{
+			input: "xadbc",
+			patterns: []string{
+				"*[ab]c",
+			},
+			output: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -228,7 +250,96 @@ func TestAddPattern(t *testing.T) {
 	require.Equal(1, len(b.patterns))
 	ast, err := parser.Parse("test", "pattern")
 	require.NoError(err)
-	require.Equal(ast, b.patterns["test"])
+	require.Equal([]*parser.Node{ast}, b.patterns["test"])
+}
+
+func TestAddPatterns(t *testing.T) {
+	require := r.New(t)
+
+	patterns := map[string]string{
+		"good":  "foo*",
+		"bad":   "[foo",
+		"worse": `[z-a]\q`,
+		"fine":  "*bar",
+	}
+
+	b := New()
+	err := b.AddPatterns(patterns)
+
+	var errs LoadErrors
+	require.ErrorAs(err, &errs)
+	require.Len(errs, 2)
+	require.Equal("bad", errs[0].Name)
+	require.Equal("worse", errs[1].Name)
+	require.Len(errs[1].Err, 2)
+	require.Empty(b.patterns)
+
+	var pe *ParseError
+	require.ErrorAs(err, &pe)
+	require.Equal("bad", pe.Name)
+
+	b = New()
+	b.SetLenient(true)
+	err = b.AddPatterns(patterns)
+	require.ErrorAs(err, &errs)
+	require.Len(errs, 2)
+	require.Equal([]string{"fine", "good"}, b.order)
+
+	mg := b.MustCompile()
+	require.True(mg.Match("food"))
+	require.True(mg.Match("rebar"))
+
+	b = New()
+	require.NoError(b.AddPatterns(map[string]string{"a": "a", "b": "b"}))
+	require.Equal([]string{"a", "b"}, b.order)
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("a", "foo*")
+	b.MustAddPattern("a", "bar*")
+	mg := b.MustCompile()
+	require.False(mg.Match("football"))
+	require.True(mg.Match("barney"))
+
+	b = New()
+	b.SetDuplicatePolicy(DuplicateError)
+	b.MustAddPattern("a", "foo*")
+	err := b.AddPattern("a", "bar*")
+	require.ErrorIs(err, ErrDuplicateName)
+	require.False(b.MustCompile().Match("barney"))
+
+	b = New()
+	b.SetDuplicatePolicy(DuplicateKeepAll)
+	b.MustAddPattern("a", "foo*")
+	b.MustAddPattern("b", "*ball")
+	b.MustAddPattern("a", "*ney")
+	mg = b.MustCompile()
+
+	require.Equal([]string{"a"}, mg.FindAllPatterns("barney"))
+	output := mg.FindAllPatterns("football")
+	sort.Strings(output)
+	require.Equal([]string{"a", "b"}, output)
+
+	globs, index, err := mg.FindGlobsForPatternIndex("football", "a")
+	require.NoError(err)
+	require.Equal(0, index)
+	require.Equal([]string{"tball"}, globs)
+
+	globs, index, err = mg.FindGlobsForPatternIndex("barney", "a")
+	require.NoError(err)
+	require.Equal(1, index)
+	require.Equal([]string{"bar"}, globs)
+
+	name, globs, ok := mg.FindGlobs("barney")
+	require.True(ok)
+	require.Equal("a", name)
+	require.Equal([]string{"bar"}, globs)
+
+	_, _, err = mg.FindGlobsForPatternIndex("pen", "a")
+	require.Error(err)
 }
 
 func TestFindAllPatterns(t *testing.T) {
@@ -486,6 +597,19 @@ func TestExtractGlobs(t *testing.T) {
 			output:  nil,
 			err:     true,
 		},
+		{
+			input:   "testing",
+			pattern: "test",
+			output:  nil,
+			err:     true,
+		},
+		{
+			input:   "éèx",
+			pattern: "[éè]+x",
+			output: []string{
+				"éè",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -495,7 +619,7 @@ func TestExtractGlobs(t *testing.T) {
 			ast, err := parser.Parse(test.pattern, test.pattern)
 			require.NoError(err)
 
-			output, err := extractGlobs(test.input, ast)
+			output, err := extractGlobs(test.input, ast, CaptureGreedy)
 			if test.err {
 				require.Error(err)
 			} else {
@@ -746,3 +870,15 @@ func TestFindAllGlobs(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeCopies(t *testing.T) {
+	require := r.New(t)
+
+	names := make([]string, 1, 4)
+	names[0] = "a"
+	merged := merge(names, []string{"b"})
+	require.Equal([]string{"a", "b"}, merged)
+
+	// The spare capacity of names is left alone
+	require.Equal([]string{"a", ""}, names[:2])
+}
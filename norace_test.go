@@ -0,0 +1,6 @@
+//go:build !race
+
+package multiglob
+
+// raceEnabled reports whether the package was built with the race detector.
+const raceEnabled = false
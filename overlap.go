@@ -0,0 +1,38 @@
+package multiglob
+
+import (
+	"github.com/szabado/multiglob/internal/automaton"
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// Overlap is a pair of patterns that can both match the same input. FindPattern can return
+// either of them for Example.
+type Overlap struct {
+	A, B    string // Names of the patterns, with A < B
+	Example string // One of the shortest inputs both patterns match
+}
+
+// Overlaps returns every pair of patterns that can match the same input, sorted by name.
+// Patterns kept under the same name aren't compared with each other.
+//
+// The patterns are compared through the merged tree, so patterns that can't match the
+// same input because they start with different text cost next to nothing.
+func (mg *MultiGlob) Overlaps() []Overlap {
+	return overlaps(mg.node)
+}
+
+// Overlaps returns every pair of patterns added to the builder that can match the same input.
+// See MultiGlob.Overlaps. Unlike Compile, it doesn't check the templates.
+func (m *Builder) Overlaps() []Overlap {
+	return overlaps(m.tree())
+}
+
+func overlaps(root *parser.Node) []Overlap {
+	found := automaton.Overlaps(automaton.New(root))
+
+	overlaps := make([]Overlap, len(found))
+	for i, o := range found {
+		overlaps[i] = Overlap(o)
+	}
+	return overlaps
+}
@@ -0,0 +1,56 @@
+package multiglob
+
+import (
+	"fmt"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestOverlaps(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.SetDuplicatePolicy(DuplicateKeepAll)
+	b.MustAddPattern("logs", "logs/*")
+	b.MustAddPattern("errors", "*.err")
+	b.MustAddPattern("metrics", "metrics/[a-z]+")
+	b.MustAddPattern("cpu", "metrics/cpu*")
+	b.MustAddPattern("logs", "logs/[a-z]")
+
+	overlaps := b.Overlaps()
+	require.Equal([]Overlap{
+		{A: "cpu", B: "errors", Example: "metrics/cpu.err"},
+		{A: "cpu", B: "metrics", Example: "metrics/cpu"},
+		{A: "errors", B: "logs", Example: "logs/.err"},
+	}, overlaps)
+
+	mg := b.MustCompile()
+	for _, o := range overlaps {
+		require.ElementsMatch([]string{o.A, o.B}, mg.FindAllPatterns(o.Example))
+	}
+}
+
+func TestOverlapsDisjoint(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	for i := 0; i < 500; i++ {
+		b.MustAddPattern(fmt.Sprint(i), fmt.Sprintf("service-%d/*", i))
+	}
+	require.Empty(b.Overlaps())
+}
+
+func TestBuilderOverlapsIgnoresTemplates(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("a", "logs/*")
+	b.MustAddPattern("b", "logs/*.log")
+	require.NoError(b.SetTemplate("missing", "archive/$1"))
+
+	// The template keeps the builder from compiling, but not from finding the overlap
+	_, err := b.Compile()
+	require.Error(err)
+	require.Equal([]Overlap{{A: "a", B: "b", Example: "logs/.log"}}, b.Overlaps())
+}
@@ -0,0 +1,137 @@
+package multiglob
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// ParseErrorKind identifies the problem described by a ParseError.
+type ParseErrorKind int
+
+const (
+	// ParseUnclosedRange is a range without a closing bracket, like "[ab".
+	ParseUnclosedRange = ParseErrorKind(parser.UnclosedRange)
+	// ParseReversedBounds is a character range with its bounds out of order, like "[z-a]".
+	ParseReversedBounds = ParseErrorKind(parser.ReversedBounds)
+	// ParseIncompleteBounds is a character range without an upper bound, like "[a-]".
+	ParseIncompleteBounds = ParseErrorKind(parser.IncompleteBounds)
+	// ParseUnknownEscape is an escape of a character that doesn't need it, like "\a".
+	ParseUnknownEscape = ParseErrorKind(parser.UnknownEscape)
+	// ParseTrailingEscape is an escape at the end of the pattern.
+	ParseTrailingEscape = ParseErrorKind(parser.TrailingEscape)
+)
+
+func (k ParseErrorKind) String() string {
+	switch k {
+	case ParseUnclosedRange:
+		return "unclosed range"
+	case ParseReversedBounds:
+		return "reversed bounds"
+	case ParseIncompleteBounds:
+		return "incomplete bounds"
+	case ParseUnknownEscape:
+		return "unknown escape"
+	case ParseTrailingEscape:
+		return "trailing escape"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseError describes a syntax error in a pattern. When a pattern can't be added because of
+// its syntax, the returned error wraps a *ParseError, which can be retrieved with errors.As.
+type ParseError struct {
+	Name    string         // Name of the pattern
+	Pattern string         // The pattern that failed to parse
+	Kind    ParseErrorKind // What the problem is
+	Offset  int            // Byte offset of Token in Pattern
+	Index   int            // Rune offset of Token in Pattern
+	Token   string         // The part of the pattern the problem is with
+
+	err *parser.Error
+}
+
+func (e *ParseError) Error() string {
+	return e.err.Error()
+}
+
+// Caret returns the pattern, followed by a line with a caret pointing at the problem:
+//
+//	foo[z-a]
+//	    ^
+func (e *ParseError) Caret() string {
+	var b strings.Builder
+	b.WriteString(e.Pattern)
+	b.WriteByte('\n')
+
+	offset := min(max(e.Offset, 0), len(e.Pattern))
+
+	// Keep tabs so that the caret lines up with the pattern
+	for _, r := range e.Pattern[:offset] {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteByte('^')
+
+	return b.String()
+}
+
+// ParseErrors is returned in place of a *ParseError when a pattern has more than one syntax
+// error. errors.As finds the first one.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	switch len(e) {
+	case 0:
+		return "no errors"
+	case 1:
+		return e[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", e[0], len(e)-1)
+	}
+}
+
+// Unwrap returns the errors in the list.
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// parse parses the pattern, converting syntax errors to *ParseError and ParseErrors.
+func parse(name, pattern string) (*parser.Node, error) {
+	p, err := parser.Parse(name, pattern)
+	switch err := err.(type) {
+	case nil:
+		return p, nil
+	case *parser.Error:
+		return nil, newParseError(err)
+	case parser.ErrorList:
+		errs := make(ParseErrors, len(err))
+		for i, pe := range err {
+			errs[i] = newParseError(pe)
+		}
+		return nil, errs
+	default:
+		return nil, err
+	}
+}
+
+func newParseError(pe *parser.Error) *ParseError {
+	return &ParseError{
+		Name:    pe.Name,
+		Pattern: pe.Pattern,
+		Kind:    ParseErrorKind(pe.Kind),
+		Offset:  pe.Offset,
+		Index:   pe.Index,
+		Token:   pe.Token,
+		err:     pe,
+	}
+}
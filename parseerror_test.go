@@ -0,0 +1,108 @@
+package multiglob
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		pattern string
+		kind    ParseErrorKind
+		offset  int
+		index   int
+		token   string
+		message string
+		caret   string
+	}{
+		{
+			pattern: "foo[ab",
+			kind:    ParseUnclosedRange,
+			offset:  3,
+			index:   3,
+			token:   "[",
+			message: "failed to parse foo[ab: unclosed range missing ]",
+			caret:   "foo[ab\n   ^",
+		},
+		{
+			pattern: "fö[z-a]",
+			kind:    ParseReversedBounds,
+			offset:  4,
+			index:   3,
+			token:   "z-a",
+			message: "failed to parse fö[z-a]: character range (z, a) is out of order",
+			caret:   "fö[z-a]\n   ^",
+		},
+		{
+			pattern: "[ab-]",
+			kind:    ParseIncompleteBounds,
+			offset:  2,
+			index:   2,
+			token:   "b-",
+			message: "failed to parse [ab-]: invalid range syntax b-",
+			caret:   "[ab-]\n  ^",
+		},
+		{
+			pattern: `[a\b]`,
+			kind:    ParseUnknownEscape,
+			offset:  2,
+			index:   2,
+			token:   `\b`,
+			message: `failed to parse [a\b]: unknown escaping: \b`,
+			caret:   "[a\\b]\n  ^",
+		},
+		{
+			pattern: "\tab\\c",
+			kind:    ParseUnknownEscape,
+			offset:  3,
+			index:   3,
+			token:   `\c`,
+			message: "failed to parse \tab\\c: unknown escaping: \\c",
+			caret:   "\tab\\c\n\t  ^",
+		},
+		{
+			pattern: `abc\`,
+			kind:    ParseTrailingEscape,
+			offset:  3,
+			index:   3,
+			token:   `\`,
+			message: `failed to parse abc\: escape found at end of pattern`,
+			caret:   "abc\\\n   ^",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			err := New().AddPattern("name", test.pattern)
+			require.Error(err)
+
+			var pe *ParseError
+			require.True(errors.As(err, &pe))
+			require.Equal("name", pe.Name)
+			require.Equal(test.pattern, pe.Pattern)
+			require.Equal(test.kind, pe.Kind)
+			require.Equal(test.offset, pe.Offset)
+			require.Equal(test.index, pe.Index)
+			require.Equal(test.token, pe.Token)
+			require.Equal(test.message, pe.Error())
+			require.Equal(test.caret, pe.Caret())
+		})
+	}
+}
+
+func TestParseErrorFromLoad(t *testing.T) {
+	require := r.New(t)
+
+	err := New().Load(strings.NewReader("ok\tfoo\nbad\t[z-a]\n"), FormatText)
+
+	var pe *ParseError
+	require.True(errors.As(err, &pe))
+	require.Equal("bad", pe.Name)
+	require.Equal(ParseReversedBounds, pe.Kind)
+	require.Equal("reversed bounds", pe.Kind.String())
+}
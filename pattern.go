@@ -0,0 +1,44 @@
+package multiglob
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// FormatPattern parses the pattern and returns it in canonical form, so that patterns that
+// only differ in how they're written format the same: redundant escapes are dropped, ranges
+// are sorted and deduplicated, and single character ranges like "[a]" are written as "a".
+func FormatPattern(pattern string) (string, error) {
+	p, err := parse(pattern, pattern)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to format pattern")
+	}
+	return p.String(), nil
+}
+
+// Names returns the names of the patterns in the MultiGlob, sorted.
+func (mg *MultiGlob) Names() []string {
+	names := make([]string, 0, len(mg.patterns))
+	for name := range mg.patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Patterns returns the patterns stored under the name, in the order they were added. They're
+// rebuilt from the compiled patterns, so they come back in the canonical form of FormatPattern.
+// If a separator is set, a ** is kept exactly where it makes up a whole segment.
+func (mg *MultiGlob) Patterns(name string) []string {
+	asts := mg.patterns[name]
+	if len(asts) == 0 {
+		return nil
+	}
+
+	patterns := make([]string, len(asts))
+	for i, p := range asts {
+		patterns[i] = p.Format(mg.separator)
+	}
+	return patterns
+}
@@ -0,0 +1,105 @@
+package multiglob
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestFormatPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		output  string
+	}{
+		{pattern: "", output: ""},
+		{pattern: "foo", output: "foo"},
+		{pattern: "foo**bar***", output: "foo*bar*"},
+		{pattern: "svc.**.p99", output: "svc.**.p99"},
+		{pattern: "**", output: "**"},
+		{pattern: "**/x", output: "**/x"},
+		{pattern: "x/***", output: "x/**"},
+		{pattern: "logs/**.log", output: "logs/*.log"},
+		{pattern: "[.a]**.x", output: "[.a]*.x"},
+		{pattern: "[a]", output: "a"},
+		{pattern: "x[*]y", output: `x\*y`},
+		{pattern: "[cba]", output: "[a-c]"},
+		{pattern: "[ba]+", output: "[ab]+"},
+		{pattern: "[a-fb-dzz]", output: "[a-fz]"},
+		{pattern: "[^a]", output: "[^a]"},
+		{pattern: `[\^]`, output: "^"},
+		{pattern: `[\^a]`, output: `[\^a]`},
+		{pattern: `[a\^]`, output: `[\^a]`},
+		{pattern: `[\-\]a]`, output: `[\-\]a]`},
+		{pattern: `\*\[\]\\`, output: `\*\[\]\\`},
+		{pattern: "a]", output: `a\]`},
+		{pattern: "a+b", output: "ab"},
+		{pattern: "*+*", output: "*"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			output, err := FormatPattern(test.pattern)
+			require.NoError(err)
+			require.Equal(test.output, output)
+
+			// Formatting is stable, and doesn't change what matches
+			again, err := FormatPattern(output)
+			require.NoError(err)
+			require.Equal(output, again)
+
+			subsumes, _, err := Subsumes(test.pattern, output)
+			require.NoError(err)
+			require.True(subsumes)
+			subsumes, _, err = Subsumes(output, test.pattern)
+			require.NoError(err)
+			require.True(subsumes)
+		})
+	}
+
+	_, err := FormatPattern("[a")
+	r.EqualError(t, err, "failed to format pattern: failed to parse [a: unclosed range missing ]")
+}
+
+func TestPatterns(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.SetDuplicatePolicy(DuplicateKeepAll)
+	b.MustAddPattern("logs", "logs/**.log")
+	b.MustAddPattern("logs", "logs/[ba]")
+	b.MustAddPattern("metrics", `metrics/\*`)
+	mg := b.MustCompile()
+
+	require.Equal([]string{"logs", "metrics"}, mg.Names())
+	require.Equal([]string{"logs/*.log", "logs/[ab]"}, mg.Patterns("logs"))
+	require.Equal([]string{`metrics/\*`}, mg.Patterns("metrics"))
+	require.Nil(mg.Patterns("missing"))
+
+	// The patterns survive encoding, so they can be recovered from a saved MultiGlob
+	data, err := mg.MarshalBinary()
+	require.NoError(err)
+	var loaded MultiGlob
+	require.NoError(loaded.UnmarshalBinary(data))
+	require.Equal(mg.Patterns("logs"), loaded.Patterns("logs"))
+}
+
+func TestPatternsRoundTrip(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	require.NoError(b.SetSeparator('.'))
+	b.MustAddPattern("p99", "svc.**.p99")
+	mg := b.MustCompile()
+
+	// A pattern rebuilt from the MultiGlob matches the same segments as the original
+	rebuilt := New()
+	require.NoError(rebuilt.SetSeparator('.'))
+	for _, pattern := range mg.Patterns("p99") {
+		rebuilt.MustAddPattern("p99", pattern)
+	}
+	segs := []string{"svc", "a", "b", "p99"}
+	require.True(mg.MatchSegments(segs))
+	require.True(rebuilt.MustCompile().MatchSegments(segs))
+}
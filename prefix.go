@@ -0,0 +1,251 @@
+package multiglob
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/szabado/multiglob/internal/automaton"
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// Completion describes what can come after a prefix for some pattern to match. See
+// MultiGlob.Complete.
+type Completion struct {
+	Match  bool     // Whether a pattern matches the prefix as it is
+	Next   []rune   // The literal characters that can come next, sorted
+	Ranges []string // The ranges that the next character can match, in canonical form
+	Any    bool     // Whether a * lets any character come next
+}
+
+// CanMatchPrefix returns true if a pattern matches some input that starts with the prefix.
+// When walking a tree of keys, it tells whether a whole subtree can be skipped. Only the part
+// of the merged tree that the prefix leads to is walked.
+func (mg *MultiGlob) CanMatchPrefix(prefix string) bool {
+	found := false
+	walkPrefix(mg.node, prefix, func(pos prefixPosition) bool {
+		found = pos.feasible()
+		return !found
+	})
+	return found
+}
+
+// PatternsFeasibleForPrefix returns the sorted names of the patterns that match some input
+// that starts with the prefix.
+func (mg *MultiGlob) PatternsFeasibleForPrefix(prefix string) []string {
+	names := make(map[string]bool)
+	walkPrefix(mg.node, prefix, func(pos prefixPosition) bool {
+		if pos.feasible() {
+			addNames(pos.node, names)
+		}
+		return true
+	})
+
+	found := make([]string, 0, len(names))
+	for name := range names {
+		found = append(found, name)
+	}
+	sort.Strings(found)
+	return found
+}
+
+// Complete returns what the patterns allow to come after the prefix, for autocompletion.
+func (mg *MultiGlob) Complete(prefix string) Completion {
+	var (
+		c      Completion
+		ranges = make(map[string]bool)
+	)
+	walkPrefix(mg.node, prefix, func(pos prefixPosition) bool {
+		if !pos.feasible() {
+			return true
+		}
+
+		switch pos.phase {
+		case phaseBefore:
+			c.firsts(pos.node, ranges)
+		case phaseText:
+			r, _ := utf8.DecodeRuneInString(pos.node.Value[pos.offset:])
+			c.Next = append(c.Next, r)
+		case phaseLoop:
+			c.Match = c.Match || pos.node.Leaf
+			c.first(pos.node, ranges)
+			c.children(pos.node, ranges)
+		case phaseAfter:
+			c.Match = c.Match || pos.node.Leaf
+			c.children(pos.node, ranges)
+		}
+		return true
+	})
+
+	slices.Sort(c.Next)
+	c.Next = slices.Compact(c.Next)
+	for r := range ranges {
+		c.Ranges = append(c.Ranges, r)
+	}
+	sort.Strings(c.Ranges)
+	return c
+}
+
+// firsts adds what can come first when the node is reached.
+func (c *Completion) firsts(n *parser.Node, ranges map[string]bool) {
+	switch {
+	case n.Type == parser.TypeRange && emptyRange(n):
+	case n.Type == parser.TypeRoot, n.Type == parser.TypeText && n.Value == "":
+		c.Match = c.Match || n.Leaf
+		c.children(n, ranges)
+	case n.Type == parser.TypeAny:
+		c.Match = c.Match || n.Leaf
+		c.first(n, ranges)
+		c.children(n, ranges)
+	default:
+		c.first(n, ranges)
+	}
+}
+
+// first adds the first character that the node itself can match.
+func (c *Completion) first(n *parser.Node, ranges map[string]bool) {
+	switch n.Type {
+	case parser.TypeText:
+		if r, size := utf8.DecodeRuneInString(n.Value); size > 0 {
+			c.Next = append(c.Next, r)
+		}
+	case parser.TypeAny:
+		c.Any = true
+	case parser.TypeRange:
+		set := automaton.FromRange(n.Range)
+		if len(set) == 1 && set[0].Lo == set[0].Hi {
+			c.Next = append(c.Next, set[0].Lo)
+			return
+		}
+
+		// A single character of the range comes next, even if it's repeated
+		r := *n.Range
+		r.Repeated = false
+		ranges[r.String()] = true
+	}
+}
+
+func (c *Completion) children(n *parser.Node, ranges map[string]bool) {
+	for _, child := range n.Children {
+		c.firsts(child, ranges)
+	}
+}
+
+// prefixPhase says how much of a node a prefix has been matched by.
+type prefixPhase int
+
+const (
+	phaseBefore prefixPhase = iota // None of the node
+	phaseText                      // Part of the text of a text node
+	phaseLoop                      // Part of a * or repeated range, which can match more
+	phaseAfter                     // All of the node
+)
+
+// prefixPosition is where in the merged tree a prefix ends.
+type prefixPosition struct {
+	node   *parser.Node
+	phase  prefixPhase
+	offset int // For phaseText, how much of the text was matched
+}
+
+// feasible returns true if an input can match a pattern through the position.
+func (pos prefixPosition) feasible() bool {
+	if pos.phase == phaseBefore && pos.node.Type == parser.TypeRange && emptyRange(pos.node) {
+		return false
+	}
+	return hasLeaf(pos.node)
+}
+
+// hasLeaf returns true if a pattern ends at the node or below it, through nodes that can all
+// match something.
+func hasLeaf(n *parser.Node) bool {
+	if n.Leaf {
+		return true
+	}
+	for _, child := range n.Children {
+		if !(child.Type == parser.TypeRange && emptyRange(child)) && hasLeaf(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// addNames adds the names of the patterns that end at the node or below it.
+func addNames(n *parser.Node, names map[string]bool) {
+	for _, name := range n.Name {
+		names[name] = true
+	}
+	for _, child := range n.Children {
+		if !(child.Type == parser.TypeRange && emptyRange(child)) {
+			addNames(child, names)
+		}
+	}
+}
+
+// emptyRange returns true if no character matches the range of the node.
+func emptyRange(n *parser.Node) bool {
+	return automaton.FromRange(n.Range).Empty()
+}
+
+// walkPrefix calls visit with each position in the tree that the prefix can end at, until
+// visit returns false. It returns false if visit did.
+func walkPrefix(n *parser.Node, prefix string, visit func(prefixPosition) bool) bool {
+	if prefix == "" {
+		return visit(prefixPosition{node: n, phase: phaseBefore})
+	}
+
+	switch n.Type {
+	case parser.TypeRoot:
+		return walkAfter(n, prefix, visit)
+	case parser.TypeText:
+		switch {
+		case strings.HasPrefix(prefix, n.Value):
+			return walkAfter(n, prefix[len(n.Value):], visit)
+		case strings.HasPrefix(n.Value, prefix):
+			return visit(prefixPosition{node: n, phase: phaseText, offset: len(prefix)})
+		}
+	case parser.TypeAny:
+		// The * can match all of the prefix, or leave some of it to the nodes after it
+		if !visit(prefixPosition{node: n, phase: phaseLoop}) {
+			return false
+		}
+		for i := range prefix {
+			if !walkAfter(n, prefix[i:], visit) {
+				return false
+			}
+		}
+	case parser.TypeRange:
+		for end := 0; end < len(prefix); {
+			r, size := utf8.DecodeRuneInString(prefix[end:])
+			if !n.Range.Matches(r) {
+				break
+			}
+			end += size
+
+			if end == len(prefix) && n.Range.Repeated {
+				return visit(prefixPosition{node: n, phase: phaseLoop})
+			}
+			if !walkAfter(n, prefix[end:], visit) {
+				return false
+			}
+			if !n.Range.Repeated {
+				break
+			}
+		}
+	}
+	return true
+}
+
+// walkAfter walks the rest of the prefix once the node matched the part before it.
+func walkAfter(n *parser.Node, rest string, visit func(prefixPosition) bool) bool {
+	if rest == "" {
+		return visit(prefixPosition{node: n, phase: phaseAfter})
+	}
+	for _, child := range n.Children {
+		if !walkPrefix(child, rest, visit) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package multiglob
+
+import (
+	"testing"
+	"unicode"
+
+	r "github.com/stretchr/testify/require"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// withNever adds a pattern named "never" to mg, which is the text followed by a range that no
+// character matches. Patterns can't hold a NUL, so the range can only be built by hand.
+func withNever(mg *MultiGlob, text string) *MultiGlob {
+	never := &parser.Node{
+		Type: parser.TypeRoot,
+		Children: []*parser.Node{{
+			Type:  parser.TypeText,
+			Value: text,
+			Children: []*parser.Node{{
+				Type: parser.TypeRange,
+				Range: &parser.Range{
+					Inverse: true,
+					Bounds:  []*parser.Bounds{{Low: 0, High: unicode.MaxRune}},
+				},
+				Leaf: true,
+				Name: []string{"never"},
+			}},
+		}},
+	}
+	mg.node = parser.Merge(mg.node, never)
+	return mg
+}
+
+func TestPatternsFeasibleForPrefix(t *testing.T) {
+	b := New()
+	b.MustAddPattern("metric", "svc.api.*")
+	b.MustAddPattern("web", "svc.web.latency")
+	b.MustAddPattern("digits", "id-[0-9]+-x")
+	b.MustAddPattern("star", "*.log")
+	b.MustAddPattern("exact", "svc")
+	mg := withNever(b.MustCompile(), "no")
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{prefix: "", expected: []string{"digits", "exact", "metric", "star", "web"}},
+		{prefix: "s", expected: []string{"exact", "metric", "star", "web"}},
+		{prefix: "svc", expected: []string{"exact", "metric", "star", "web"}},
+		{prefix: "svc.a", expected: []string{"metric", "star"}},
+		{prefix: "svc.api.anything", expected: []string{"metric", "star"}},
+		{prefix: "svc.webx", expected: []string{"star"}},
+		{prefix: "id-12", expected: []string{"digits", "star"}},
+		{prefix: "id-12-", expected: []string{"digits", "star"}},
+		{prefix: "id-1a", expected: []string{"star"}},
+		{prefix: "no", expected: []string{"star"}},
+		{prefix: "app.log.gz", expected: []string{"star"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.prefix, func(t *testing.T) {
+			require := r.New(t)
+
+			require.Equal(test.expected, mg.PatternsFeasibleForPrefix(test.prefix))
+			require.Equal(len(test.expected) > 0, mg.CanMatchPrefix(test.prefix))
+		})
+	}
+}
+
+func TestCanMatchPrefix(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		misses  []string
+	}{
+		{
+			pattern: "abc",
+			matches: []string{"", "a", "ab", "abc"},
+			misses:  []string{"abcd", "b", "ac"},
+		},
+		{
+			pattern: "a*c",
+			matches: []string{"a", "abbb", "ac", "acc", "abcd"},
+			misses:  []string{"b", "ca"},
+		},
+		{
+			pattern: "[a-c]+d",
+			matches: []string{"", "a", "abc", "abcd"},
+			misses:  []string{"d", "abcde", "ad d"},
+		},
+		{
+			pattern: "[ab]d",
+			matches: []string{"a", "bd"},
+			misses:  []string{"ab", "c"},
+		},
+		{
+			pattern: "été*",
+			matches: []string{"é", "ét", "été", "étéx"},
+			misses:  []string{"e", "ée"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			require := r.New(t)
+			b := New()
+			b.MustAddPattern("p", test.pattern)
+			mg := b.MustCompile()
+
+			for _, prefix := range test.matches {
+				require.True(mg.CanMatchPrefix(prefix), "prefix %q", prefix)
+			}
+			for _, prefix := range test.misses {
+				require.False(mg.CanMatchPrefix(prefix), "prefix %q", prefix)
+			}
+		})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	b := New()
+	b.MustAddPattern("metric", "svc.api.*")
+	b.MustAddPattern("web", "svc.web.latency")
+	b.MustAddPattern("exact", "svc")
+	b.MustAddPattern("digits", "id-[0-9]+-x")
+	b.MustAddPattern("one", "id-[a]")
+	mg := withNever(b.MustCompile(), "svc.")
+
+	tests := []struct {
+		prefix   string
+		expected Completion
+	}{
+		{
+			prefix:   "",
+			expected: Completion{Next: []rune{'i', 's'}},
+		},
+		{
+			prefix:   "sv",
+			expected: Completion{Next: []rune{'c'}},
+		},
+		{
+			prefix:   "svc",
+			expected: Completion{Match: true, Next: []rune{'.'}},
+		},
+		{
+			prefix:   "svc.",
+			expected: Completion{Next: []rune{'a', 'w'}},
+		},
+		{
+			prefix:   "svc.api.",
+			expected: Completion{Match: true, Any: true},
+		},
+		{
+			prefix:   "svc.api.x",
+			expected: Completion{Match: true, Any: true},
+		},
+		{
+			prefix:   "id-",
+			expected: Completion{Next: []rune{'a'}, Ranges: []string{"[0-9]"}},
+		},
+		{
+			prefix:   "id-4",
+			expected: Completion{Next: []rune{'-'}, Ranges: []string{"[0-9]"}},
+		},
+		{
+			prefix:   "id-a",
+			expected: Completion{Match: true},
+		},
+		{
+			prefix:   "nothing",
+			expected: Completion{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.prefix, func(t *testing.T) {
+			require := r.New(t)
+			require.Equal(test.expected, mg.Complete(test.prefix))
+		})
+	}
+}
+
+func TestCanMatchPrefixNever(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("p", "abc")
+	mg := withNever(b.MustCompile(), "a")
+	require.False(mg.CanMatchPrefix("ax"))
+	require.True(mg.CanMatchPrefix("a"))
+	require.Equal([]string{"p"}, mg.PatternsFeasibleForPrefix("a"))
+	require.Equal(Completion{Next: []rune{'b'}}, mg.Complete("a"))
+}
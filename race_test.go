@@ -0,0 +1,7 @@
+//go:build race
+
+package multiglob
+
+// raceEnabled reports whether the package was built with the race detector. sync.Pool drops
+// items at random under it, so allocations can't be counted.
+const raceEnabled = true
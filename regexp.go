@@ -0,0 +1,310 @@
+package multiglob
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// ErrNoGlobEquivalent is returned by FromRegexp for regular expressions that no pattern
+// matches the same inputs as.
+var ErrNoGlobEquivalent = errors.New("no glob equivalent")
+
+// ToRegexp converts the pattern into an RE2 regular expression, as accepted by the regexp
+// package, that matches the same inputs. The expression is anchored at both ends, and uses
+// the s flag when the pattern has a * so that it matches newlines as well.
+func ToRegexp(pattern string) (string, error) {
+	p, err := parse(pattern, pattern)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to convert pattern")
+	}
+
+	var (
+		b      strings.Builder
+		dotAll = false
+	)
+	b.WriteByte('^')
+	for n := nextNode(p); n != nil; n = nextNode(n) {
+		switch n.Type {
+		case parser.TypeText:
+			b.WriteString(regexp.QuoteMeta(n.Value))
+		case parser.TypeAny:
+			b.WriteString(".*")
+			dotAll = true
+		case parser.TypeRange:
+			writeRegexpClass(&b, n.Range)
+		}
+	}
+	b.WriteByte('$')
+
+	if dotAll {
+		return "(?s)" + b.String(), nil
+	}
+	return b.String(), nil
+}
+
+func writeRegexpClass(b *strings.Builder, r *parser.Range) {
+	b.WriteByte('[')
+	if r.Inverse {
+		b.WriteByte('^')
+	}
+	for _, c := range r.CharList {
+		writeRegexpClassRune(b, c)
+	}
+	for _, bound := range r.Bounds {
+		writeRegexpClassRune(b, bound.Low)
+		b.WriteByte('-')
+		writeRegexpClassRune(b, bound.High)
+	}
+	b.WriteByte(']')
+
+	if r.Repeated {
+		b.WriteByte('+')
+	}
+}
+
+func writeRegexpClassRune(b *strings.Builder, r rune) {
+	switch {
+	case strings.ContainsRune(`\]-[^`, r):
+		b.WriteByte('\\')
+		b.WriteRune(r)
+	case unicode.IsPrint(r):
+		b.WriteRune(r)
+	default:
+		fmt.Fprintf(b, `\x{%x}`, r)
+	}
+}
+
+// FromRegexp converts an RE2 regular expression into a pattern that matches the same inputs.
+// Like regexp.MatchString, the expression matches anywhere in the input unless it's anchored
+// with ^ and $.
+//
+// Only some expressions can be converted: literal text, character classes, a class repeated
+// with +, and .* with the s flag, which can match newlines like * does. Anything else returns
+// an error wrapping ErrNoGlobEquivalent.
+func FromRegexp(expr string) (string, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse regular expression")
+	}
+	re = re.Simplify()
+
+	subs := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		subs = re.Sub
+	}
+
+	c := &globConverter{}
+	if len(subs) > 0 && subs[0].Op == syntax.OpBeginText {
+		subs = subs[1:]
+	} else {
+		c.writeAny()
+	}
+
+	anchoredEnd := len(subs) > 0 && subs[len(subs)-1].Op == syntax.OpEndText
+	if anchoredEnd {
+		subs = subs[:len(subs)-1]
+	}
+
+	for _, sub := range subs {
+		if err := c.convert(sub); err != nil {
+			return "", err
+		}
+	}
+
+	if !anchoredEnd {
+		c.writeAny()
+	}
+
+	pattern := c.b.String()
+	if _, err := parse(pattern, pattern); err != nil {
+		return "", errors.Wrapf(ErrNoGlobEquivalent, "failed to convert %s: %s", expr, err)
+	}
+	return pattern, nil
+}
+
+type globConverter struct {
+	b       strings.Builder
+	lastAny bool // Whether the pattern ends with a *, so that another one isn't needed
+}
+
+func (c *globConverter) writeAny() {
+	if !c.lastAny {
+		c.b.WriteByte('*')
+		c.lastAny = true
+	}
+}
+
+func (c *globConverter) convert(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return nil
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := c.convert(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	case syntax.OpCapture:
+		return c.convert(re.Sub[0])
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 {
+				if err := c.writeRange(foldCase(r), false, re); err != nil {
+					return err
+				}
+			} else {
+				c.writeText(r)
+			}
+		}
+		return nil
+	case syntax.OpCharClass:
+		return c.writeRange(re.Rune, false, re)
+	case syntax.OpAnyCharNotNL:
+		return c.writeRange([]rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}, false, re)
+	case syntax.OpStar:
+		if re.Sub[0].Op == syntax.OpAnyChar {
+			c.writeAny()
+			return nil
+		}
+		return noGlobEquivalent(re, "only .* with the s flag can repeat zero or more times")
+	case syntax.OpPlus:
+		sub := re.Sub[0]
+		switch {
+		case sub.Op == syntax.OpCharClass:
+			return c.writeRange(sub.Rune, true, re)
+		case sub.Op == syntax.OpAnyCharNotNL:
+			return c.writeRange([]rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}, true, re)
+		case sub.Op == syntax.OpLiteral && len(sub.Rune) == 1:
+			if sub.Flags&syntax.FoldCase != 0 {
+				return c.writeRange(foldCase(sub.Rune[0]), true, re)
+			}
+			return c.writeRange([]rune{sub.Rune[0], sub.Rune[0]}, true, re)
+		}
+		return noGlobEquivalent(re, "only a single character or class can repeat with +")
+	case syntax.OpAnyChar:
+		return noGlobEquivalent(re, "a single character of any kind can't be matched")
+	case syntax.OpAlternate:
+		return noGlobEquivalent(re, "alternation isn't supported")
+	case syntax.OpQuest, syntax.OpRepeat:
+		return noGlobEquivalent(re, "optional and counted repetition aren't supported")
+	case syntax.OpBeginText, syntax.OpEndText:
+		return noGlobEquivalent(re, "anchors are only supported at the ends")
+	default:
+		return noGlobEquivalent(re, "unsupported construct")
+	}
+}
+
+func noGlobEquivalent(re *syntax.Regexp, reason string) error {
+	return errors.Wrapf(ErrNoGlobEquivalent, "failed to convert %s: %s", re, reason)
+}
+
+func (c *globConverter) writeText(r rune) {
+	c.lastAny = false
+	switch r {
+	case '*', '[', ']', '\\':
+		c.b.WriteByte('\\')
+		c.b.WriteRune(r)
+	case '+':
+		// A + outside of a range can't be escaped
+		c.b.WriteString("[+]")
+	default:
+		c.b.WriteRune(r)
+	}
+}
+
+// writeRange writes a range matching the runes of a class, which come in pairs of bounds as in
+// syntax.Regexp.
+func (c *globConverter) writeRange(class []rune, repeated bool, re *syntax.Regexp) error {
+	inverse := false
+	if len(class) > 0 && class[len(class)-1] == unicode.MaxRune {
+		// Classes like [^a] are shorter the way they were written
+		inverse = true
+		class = invertClass(class)
+	}
+
+	if len(class) == 0 {
+		return noGlobEquivalent(re, "a single character of any kind can't be matched")
+	}
+	if !repeated && !inverse && len(class) == 2 && class[0] == class[1] {
+		c.writeText(class[0])
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteByte('[')
+	if inverse {
+		b.WriteByte('^')
+	}
+	for i := 0; i < len(class); i += 2 {
+		lo, hi := class[i], class[i+1]
+		for _, r := range []rune{lo, hi} {
+			if r == '\\' || r == 0 {
+				return noGlobEquivalent(re, fmt.Sprintf("ranges can't hold %q", r))
+			}
+		}
+
+		writeGlobRangeRune(&b, lo)
+		if hi > lo {
+			if hi > lo+1 {
+				b.WriteByte('-')
+			}
+			writeGlobRangeRune(&b, hi)
+		}
+	}
+	b.WriteByte(']')
+	if repeated {
+		b.WriteByte('+')
+	}
+
+	c.b.WriteString(b.String())
+	c.lastAny = false
+	return nil
+}
+
+func writeGlobRangeRune(b *strings.Builder, r rune) {
+	if strings.ContainsRune(`]-[^`, r) {
+		b.WriteByte('\\')
+	}
+	b.WriteRune(r)
+}
+
+// invertClass returns the pairs of bounds of the runes that aren't in class.
+func invertClass(class []rune) []rune {
+	var out []rune
+	next := rune(0)
+	for i := 0; i < len(class); i += 2 {
+		if class[i] > next {
+			out = append(out, next, class[i]-1)
+		}
+		next = class[i+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, next, unicode.MaxRune)
+	}
+	return out
+}
+
+// foldCase returns the class of runes that r matches when case is ignored.
+func foldCase(r rune) []rune {
+	runes := []rune{r}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		runes = append(runes, f)
+	}
+
+	slices.Sort(runes)
+
+	var class []rune
+	for _, f := range runes {
+		class = append(class, f, f)
+	}
+	return class
+}
@@ -0,0 +1,145 @@
+package multiglob
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		output  string
+	}{
+		{pattern: "", output: `^$`},
+		{pattern: "foo", output: `^foo$`},
+		{pattern: "foo*.log", output: `(?s)^foo.*\.log$`},
+		{pattern: "v[0-9]+.[a-cx]", output: `^v[0-9]+\.[xa-c]$`},
+		{pattern: "[^ab]", output: `^[^ab]$`},
+		{pattern: `[\^\-\]]`, output: `^[\^\-\]]$`},
+		{pattern: `a\*(b)`, output: `^a\*\(b\)$`},
+		{pattern: "[\t]", output: `^[\x{9}]$`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			output, err := ToRegexp(test.pattern)
+			require.NoError(err)
+			require.Equal(test.output, output)
+		})
+	}
+
+	_, err := ToRegexp("[a")
+	require := r.New(t)
+	require.EqualError(err, "failed to convert pattern: failed to parse [a: unclosed range missing ]")
+}
+
+func TestToRegexpMatches(t *testing.T) {
+	patterns := []string{
+		"foo*.log",
+		"*[^a-z]+",
+		"v[0-9]+.[0-9]",
+		`[\^\-\]]x*`,
+		"line\n*",
+		"[α-ω]+",
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			expr, err := ToRegexp(pattern)
+			require.NoError(err)
+			re := regexp.MustCompile(expr)
+
+			g, err := NewGenerator(pattern, GenerateOptions{Alphabet: "ab\n.-^]"})
+			require.NoError(err)
+			for i := 0; i < 100; i++ {
+				match, _ := g.Match(rng)
+				require.True(re.MatchString(match), match)
+
+				if miss, ok := g.NearMiss(rng); ok {
+					require.False(re.MatchString(miss), miss)
+				}
+			}
+		})
+	}
+}
+
+func TestFromRegexp(t *testing.T) {
+	tests := []struct {
+		expr   string
+		output string
+		err    string
+	}{
+		{expr: `^foo$`, output: "foo"},
+		{expr: `foo`, output: "*foo*"},
+		{expr: `(?s)^foo.*`, output: "foo*"},
+		{expr: `(?s).*foo.*`, output: "*foo*"},
+		{expr: `^v[0-9]+\.(log|txt)$`, err: `failed to convert log|txt: alternation isn't supported: no glob equivalent`},
+		{expr: `^v[0-9]+\.[a-c]$`, output: "v[0-9]+.[a-c]"},
+		{expr: `^(?i)ab$`, output: "[Aa][Bb]"},
+		{expr: `^[^\n]$`, output: "[^\n]"},
+		{expr: `^.+$`, output: "[^\n]+"},
+		{expr: `^a+b$`, output: "[a]+b"},
+		{expr: `^a\+b\*\[$`, output: `a[+]b\*\[`},
+		{expr: `^x{2}$`, output: "xx"},
+		{expr: `^(a)(b)$`, output: "ab"},
+		{expr: `^[\]\-^]$`, output: `[\-\]\^]`},
+		{expr: `^x?$`, err: `failed to convert x?: optional and counted repetition aren't supported: no glob equivalent`},
+		{expr: `^.*$`, err: `failed to convert (?-s:.*): only .* with the s flag can repeat zero or more times: no glob equivalent`},
+		{expr: `(?s)^.$`, err: `failed to convert (?s:.): a single character of any kind can't be matched: no glob equivalent`},
+		{expr: `^[\\a]$`, err: `failed to convert [\\a]: ranges can't hold '\\': no glob equivalent`},
+		{expr: `^a(`, err: "failed to parse regular expression: error parsing regexp: missing closing ): `^a(`"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			require := r.New(t)
+
+			output, err := FromRegexp(test.expr)
+			if test.err != "" {
+				require.EqualError(err, test.err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(test.output, output)
+		})
+	}
+}
+
+func TestRegexpRoundTrip(t *testing.T) {
+	patterns := []string{
+		"",
+		"foo*.log",
+		"*[^a-z]+",
+		"v[0-9]+.[0-9]",
+		`[\^\-\]]x*`,
+		"[α-ω]+",
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			expr, err := ToRegexp(pattern)
+			require.NoError(err)
+
+			output, err := FromRegexp(expr)
+			require.NoError(err)
+
+			subsumes, _, err := Subsumes(pattern, output)
+			require.NoError(err)
+			require.True(subsumes, output)
+
+			subsumes, _, err = Subsumes(output, pattern)
+			require.NoError(err)
+			require.True(subsumes, output)
+		})
+	}
+}
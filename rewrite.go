@@ -0,0 +1,230 @@
+package multiglob
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// ErrNoMatch is returned by Rewrite and Translate when no pattern matches the input.
+var ErrNoMatch = errors.New("no pattern matched")
+
+// template is a parsed rewrite template.
+type template struct {
+	source string
+	parts  []templatePart
+	max    int // The highest glob referenced
+}
+
+// templatePart is either text, or a reference to a glob when glob isn't negative. Glob 0 is
+// the whole input, and the others count from 1.
+type templatePart struct {
+	text string
+	glob int
+}
+
+// parseTemplate parses a template, in which $1, $2 and so on are replaced by the globs of a
+// pattern, $0 by the whole input, and $$ by a $. ${1} can be used when a reference is
+// followed by a digit.
+func parseTemplate(source string) (*template, error) {
+	t := &template{source: source}
+
+	var text strings.Builder
+	for i := 0; i < len(source); {
+		c := source[i]
+		if c != '$' {
+			text.WriteByte(c)
+			i++
+			continue
+		}
+
+		start := i
+		rest := source[i+1:]
+		var ref string
+		switch {
+		case strings.HasPrefix(rest, "$"):
+			text.WriteByte('$')
+			i += 2
+			continue
+		case strings.HasPrefix(rest, "{"):
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return nil, errors.Errorf("unterminated ${ at offset %d", start)
+			}
+			ref = rest[1:end]
+			i += end + 2
+
+			if ref != "" && strings.Trim(ref, "0123456789") != "" {
+				return nil, errors.Errorf("invalid reference ${%s} at offset %d: patterns have no named captures, only numbered globs", ref, start)
+			}
+		default:
+			end := 0
+			for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+				end++
+			}
+			ref = rest[:end]
+			i += end + 1
+		}
+
+		n, err := strconv.Atoi(ref)
+		if err != nil {
+			return nil, errors.Errorf("invalid $ at offset %d, use $$ for a $", start)
+		}
+
+		if text.Len() > 0 {
+			t.parts = append(t.parts, templatePart{text: text.String(), glob: -1})
+			text.Reset()
+		}
+		t.parts = append(t.parts, templatePart{glob: n})
+		t.max = max(t.max, n)
+	}
+
+	if text.Len() > 0 {
+		t.parts = append(t.parts, templatePart{text: text.String(), glob: -1})
+	}
+	return t, nil
+}
+
+// expand returns the template with the references replaced by the input and its globs.
+func (t *template) expand(input string, globs []string) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		switch {
+		case p.glob < 0:
+			b.WriteString(p.text)
+		case p.glob == 0:
+			b.WriteString(input)
+		default:
+			b.WriteString(globs[p.glob-1])
+		}
+	}
+	return b.String()
+}
+
+// check returns an error if the template refers to globs that the pattern doesn't have.
+func (t *template) check(name string, ast *parser.Node) error {
+	if globs := globCount(ast); t.max > globs {
+		return errors.Errorf("template %s refers to $%d, but pattern %s has %d globs", t.source, t.max, name, globs)
+	}
+	return nil
+}
+
+// globCount returns the number of globs extracted with a pattern.
+func globCount(ast *parser.Node) int {
+	count := 0
+	for n := ast; n != nil; n = nextNode(n) {
+		if n.Type == parser.TypeAny || n.Type == parser.TypeRange {
+			count++
+		}
+	}
+	return count
+}
+
+// Rewrite finds a pattern matching the input, like FindGlobs, and returns the template with
+// the globs extracted from the input substituted into it. $1 is replaced by the first glob,
+// $2 by the second, and so on, and $0 by the whole input. Use ${1} when a reference is
+// followed by a digit, and $$ for a $. For example, with the pattern "logs/*/*.log":
+//
+//	Input:    "logs/2024/app.log"
+//	Template: "archive/$1/$2.gz"
+//	Result:   "archive/2024/app.gz"
+//
+// Patterns have no named captures, so ${name} is an error. It returns an error wrapping
+// ErrNoMatch if no pattern matches the input.
+func (mg *MultiGlob) Rewrite(input, template string) (string, error) {
+	t, err := parseTemplate(template)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse template")
+	}
+
+	name, ok := mg.FindPattern(input)
+	if !ok {
+		return "", errors.Wrapf(ErrNoMatch, "failed to rewrite %s", input)
+	}
+	return mg.rewrite(input, name, t)
+}
+
+func (mg *MultiGlob) rewrite(input, name string, t *template) (string, error) {
+	asts := mg.patterns[name]
+	globs, i, err := extractGlobsForName(input, asts, CaptureGreedy)
+	if err != nil {
+		return "", errors.Wrapf(ErrNoMatch, "failed to rewrite %s", input)
+	}
+
+	if err := t.check(name, asts[i]); err != nil {
+		return "", errors.Wrapf(err, "failed to rewrite %s", input)
+	}
+	return t.expand(input, globs), nil
+}
+
+// Translate rewrites the input like Rewrite, with the template set with Builder.SetTemplate
+// for the pattern that matches it. Only patterns with a template are considered, and if more
+// than one of them matches, the one whose name sorts first is used. It returns the name of
+// the pattern along with the result, and an error wrapping ErrNoMatch if none of them match.
+func (mg *MultiGlob) Translate(input string) (name, output string, err error) {
+	var names []string
+	for _, n := range mg.FindAllPatterns(input) {
+		if mg.templates[n] != nil {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		return "", "", errors.Wrapf(ErrNoMatch, "failed to translate %s", input)
+	}
+
+	sort.Strings(names)
+	name = names[0]
+	output, err = mg.rewrite(input, name, mg.templates[name])
+	if err != nil {
+		return "", "", err
+	}
+	return name, output, nil
+}
+
+// Template returns the template set for the named pattern, if it has one.
+func (mg *MultiGlob) Template(name string) (string, bool) {
+	t, ok := mg.templates[name]
+	if !ok {
+		return "", false
+	}
+	return t.source, true
+}
+
+// SetTemplate sets the template that MultiGlob.Translate rewrites the inputs matching the
+// named pattern with. See MultiGlob.Rewrite for the syntax. Compile fails if the template
+// refers to more globs than the pattern has, or if there's no pattern with that name.
+func (m *Builder) SetTemplate(name, template string) error {
+	t, err := parseTemplate(template)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set template of %s", name)
+	}
+
+	m.templates[name] = t
+	return nil
+}
+
+// checkTemplates returns an error if a template doesn't fit its patterns.
+func checkTemplates(templates map[string]*template, patterns map[string][]*parser.Node) error {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		asts, ok := patterns[name]
+		if !ok {
+			return errors.Errorf("template %s is set for missing pattern %s", templates[name].source, name)
+		}
+		for _, ast := range asts {
+			if err := templates[name].check(name, ast); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
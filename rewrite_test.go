@@ -0,0 +1,199 @@
+package multiglob
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		input    string
+		template string
+		output   string
+		err      bool
+	}{
+		{
+			pattern:  "logs/*/*.log",
+			input:    "logs/2024/app.log",
+			template: "archive/$1/$2.gz",
+			output:   "archive/2024/app.gz",
+		},
+		{
+			pattern:  "logs/*/*.log",
+			input:    "logs/2024/app.log",
+			template: "$2-${1}0",
+			output:   "app-20240",
+		},
+		{
+			pattern:  "logs/*/*.log",
+			input:    "logs/2024/app.log",
+			template: "backup/$0",
+			output:   "backup/logs/2024/app.log",
+		},
+		{
+			pattern:  "v[0-9]+",
+			input:    "v12",
+			template: "$$$1",
+			output:   "$12",
+		},
+		{
+			pattern:  "static",
+			input:    "static",
+			template: "moved",
+			output:   "moved",
+		},
+		{
+			pattern:  "logs/*/*.log",
+			input:    "logs/2024/app.log",
+			template: "$3",
+			err:      true,
+		},
+		{
+			pattern:  "logs/*/*.log",
+			input:    "logs/2024/app.log",
+			template: "${year}",
+			err:      true,
+		},
+		{
+			pattern:  "logs/*/*.log",
+			input:    "logs/2024/app.log",
+			template: "cost: $",
+			err:      true,
+		},
+		{
+			pattern:  "logs/*/*.log",
+			input:    "logs/2024/app.log",
+			template: "${1",
+			err:      true,
+		},
+		{
+			pattern:  "logs/*/*.log",
+			input:    "metrics/cpu",
+			template: "$1",
+			err:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			b.MustAddPattern("p", test.pattern)
+			mg := b.MustCompile()
+
+			output, err := mg.Rewrite(test.input, test.template)
+			if test.err {
+				require.Error(err)
+			} else {
+				require.NoError(err)
+			}
+			require.Equal(test.output, output)
+		})
+	}
+}
+
+func TestRewriteNoMatch(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("p", "logs/*")
+	mg := b.MustCompile()
+
+	_, err := mg.Rewrite("metrics/cpu", "$1")
+	require.ErrorIs(err, ErrNoMatch)
+}
+
+func TestTranslate(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("logs", "logs/*/*.log")
+	b.MustAddPattern("metrics", "metrics/*")
+	b.MustAddPattern("untemplated", "tmp/*")
+	require.NoError(b.SetTemplate("logs", "archive/$1/$2.gz"))
+	require.NoError(b.SetTemplate("metrics", "tsdb/$1"))
+	mg := b.MustCompile()
+
+	name, output, err := mg.Translate("logs/2024/app.log")
+	require.NoError(err)
+	require.Equal("logs", name)
+	require.Equal("archive/2024/app.gz", output)
+
+	name, output, err = mg.Translate("metrics/cpu")
+	require.NoError(err)
+	require.Equal("metrics", name)
+	require.Equal("tsdb/cpu", output)
+
+	_, _, err = mg.Translate("tmp/scratch")
+	require.ErrorIs(err, ErrNoMatch)
+
+	template, ok := mg.Template("logs")
+	require.True(ok)
+	require.Equal("archive/$1/$2.gz", template)
+	_, ok = mg.Template("untemplated")
+	require.False(ok)
+}
+
+func TestTranslateOverlapping(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("b", "logs/*")
+	b.MustAddPattern("a", "logs/*.log")
+	require.NoError(b.SetTemplate("a", "a/$1"))
+	require.NoError(b.SetTemplate("b", "b/$1"))
+	mg := b.MustCompile()
+
+	// Both match, and a sorts first
+	name, output, err := mg.Translate("logs/app.log")
+	require.NoError(err)
+	require.Equal("a", name)
+	require.Equal("a/app", output)
+}
+
+func TestSetTemplateErrors(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("logs", "logs/*")
+	require.Error(b.SetTemplate("logs", "${name}"))
+
+	require.NoError(b.SetTemplate("logs", "$2"))
+	_, err := b.Compile()
+	require.Error(err)
+
+	require.NoError(b.SetTemplate("logs", "$1"))
+	require.NoError(b.SetTemplate("missing", "$1"))
+	_, err = b.Compile()
+	require.Error(err)
+}
+
+func TestTemplatesAfterUpdates(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("logs", "logs/*/*")
+	require.NoError(b.SetTemplate("logs", "$2/$1"))
+	mg := b.MustCompile()
+
+	require.Error(mg.ReplacePattern("logs", "logs/*"))
+	require.NoError(mg.ReplacePattern("logs", "old-logs/*/*"))
+	_, output, err := mg.Translate("old-logs/a/b")
+	require.NoError(err)
+	require.Equal("b/a", output)
+
+	data, err := mg.MarshalBinary()
+	require.NoError(err)
+	var decoded MultiGlob
+	require.NoError(decoded.UnmarshalBinary(data))
+	_, output, err = decoded.Translate("old-logs/a/b")
+	require.NoError(err)
+	require.Equal("b/a", output)
+
+	require.NoError(mg.RemovePattern("logs"))
+	_, ok := mg.Template("logs")
+	require.False(ok)
+}
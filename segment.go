@@ -0,0 +1,169 @@
+package multiglob
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// SetSeparator sets the rune that splits patterns into segments for MatchSegments. Each
+// segment of a pattern matches exactly one segment of the input, and a segment that is just **
+// matches any number of them, including none: with '.' as the separator, "svc.*.latency.**"
+// matches {"svc", "api", "latency", "p99"} and {"svc", "web", "latency"}. The separator can't
+// be one of the characters that have a meaning in patterns.
+func (m *Builder) SetSeparator(sep rune) error {
+	if sep <= 0 || sep == utf8.RuneError || !utf8.ValidRune(sep) || strings.ContainsRune(`*[]\+^-`, sep) {
+		return errors.Errorf("invalid separator %q", sep)
+	}
+
+	m.separator = sep
+	return nil
+}
+
+// segmentTree merges the patterns, split into segments on sep.
+func segmentTree(patterns map[string][]*parser.Node, sep rune) *parser.Node {
+	final := &parser.Node{Type: parser.TypeRoot}
+	for _, asts := range patterns {
+		for _, p := range asts {
+			final = parser.Merge(final, parser.Segment(p, sep))
+		}
+	}
+	return final
+}
+
+// MatchSegments determines if any pattern matches the segments, as split by the separator set
+// with Builder.SetSeparator. It's the same as joining the segments with the separator and
+// matching the result, except that a * doesn't match across segments, and ** as a segment of
+// its own matches any number of them. Without a separator, only a single segment can match.
+func (mg *MultiGlob) MatchSegments(segs []string) bool {
+	_, matched := mg.matchSegments(segs, false)
+	return matched
+}
+
+// FindAllPatternsSegments returns the names of all the patterns that match the segments, like
+// FindAllPatterns. See MatchSegments.
+func (mg *MultiGlob) FindAllPatternsSegments(segs []string) []string {
+	results, _ := mg.matchSegments(segs, true)
+	return dedupe(results)
+}
+
+func (mg *MultiGlob) matchSegments(segs []string, exhaustive bool) ([]string, bool) {
+	switch {
+	case len(segs) == 0:
+		return nil, false
+	case mg.segments == nil:
+		if len(segs) != 1 {
+			return nil, false
+		}
+		return match(mg.node, segs[0], exhaustive)
+	}
+
+	s := segmentMatcher{segs: segs, exhaustive: exhaustive}
+	return s.match(mg.segments, 0, 0)
+}
+
+// segmentMatcher matches a tree built by parser.Segment against segments.
+type segmentMatcher struct {
+	segs       []string
+	exhaustive bool
+}
+
+// match matches the node at the offset pos of the segment i.
+func (s *segmentMatcher) match(node *parser.Node, i, pos int) ([]string, bool) {
+	var results []string
+
+	// next matches what's left after the node matched up to the offset end of the segment j
+	next := func(j, end int) bool {
+		if node.Leaf && j == len(s.segs)-1 && end == len(s.segs[j]) {
+			if !s.exhaustive {
+				results = node.Name
+				return true
+			}
+			results = merge(results, node.Name)
+		}
+
+		for _, child := range node.Children {
+			names, ok := s.match(child, j, end)
+			if !ok {
+				continue
+			}
+			if !s.exhaustive {
+				results = names
+				return true
+			}
+			results = merge(results, names)
+		}
+		return false
+	}
+
+	seg := s.segs[i]
+	switch node.Type {
+	case parser.TypeRoot:
+		next(i, pos)
+	case parser.TypeText:
+		if strings.HasPrefix(seg[pos:], node.Value) {
+			next(i, pos+len(node.Value))
+		}
+	case parser.TypeAny:
+		for end := pos; ; {
+			if next(i, end) || end == len(seg) {
+				break
+			}
+			_, size := utf8.DecodeRuneInString(seg[end:])
+			end += size
+		}
+	case parser.TypeRange:
+		for end := pos; end < len(seg); {
+			r, size := utf8.DecodeRuneInString(seg[end:])
+			if !node.Range.Matches(r) {
+				break
+			}
+			end += size
+			if next(i, end) || !node.Range.Repeated {
+				break
+			}
+		}
+	case parser.TypeSeparator:
+		if pos == len(seg) && i+1 < len(s.segs) {
+			next(i+1, 0)
+		}
+	case parser.TypeAnySegments:
+		switch {
+		case !strings.HasPrefix(node.Value, "**"):
+			// The ** comes last, and matches the rest of the segments after the separator
+			if pos == len(seg) {
+				next(len(s.segs)-1, len(s.segs[len(s.segs)-1]))
+			}
+		case node.Value == "**":
+			next(len(s.segs)-1, len(s.segs[len(s.segs)-1]))
+		case pos == 0:
+			// Skip any number of segments, along with the separator after each
+			for j := i; j < len(s.segs); j++ {
+				if next(j, 0) {
+					break
+				}
+			}
+		}
+	}
+
+	return results, len(results) != 0
+}
+
+// dedupe removes the repeated names from the results of a match, keeping their order.
+func dedupe(results []string) []string {
+	duplicates := make(map[string]bool)
+
+	cleaned := make([]string, 0, len(results))
+	for _, result := range results {
+		if duplicates[result] {
+			continue
+		}
+		duplicates[result] = true
+		cleaned = append(cleaned, result)
+	}
+
+	return cleaned
+}
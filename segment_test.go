@@ -0,0 +1,176 @@
+package multiglob
+
+import (
+	"sort"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches [][]string
+		misses  [][]string
+	}{
+		{
+			pattern: "svc.*.latency.p99",
+			matches: [][]string{
+				{"svc", "api", "latency", "p99"},
+				{"svc", "", "latency", "p99"},
+			},
+			misses: [][]string{
+				{"svc", "api", "web", "latency", "p99"},
+				{"svc", "api", "latency"},
+				{"svc.api", "latency", "p99"},
+			},
+		},
+		{
+			pattern: "svc.**.p99",
+			matches: [][]string{
+				{"svc", "p99"},
+				{"svc", "api", "p99"},
+				{"svc", "api", "latency", "p99"},
+			},
+			misses: [][]string{
+				{"svc"},
+				{"svc", "api", "p95"},
+				{"svcx", "p99"},
+			},
+		},
+		{
+			pattern: "svc.**",
+			matches: [][]string{
+				{"svc"},
+				{"svc", "api"},
+				{"svc", "api", "latency"},
+			},
+			misses: [][]string{
+				{"svcx"},
+				{"api", "svc"},
+			},
+		},
+		{
+			pattern: "**.p99",
+			matches: [][]string{
+				{"p99"},
+				{"svc", "p99"},
+			},
+			misses: [][]string{
+				{"p99", "svc"},
+				{"xp99"},
+			},
+		},
+		{
+			pattern: "**",
+			matches: [][]string{
+				{""},
+				{"a"},
+				{"a", "b", "c"},
+			},
+			misses: [][]string{
+				{},
+			},
+		},
+		{
+			pattern: "svc.api*.[a-z]+",
+			matches: [][]string{
+				{"svc", "api", "latency"},
+				{"svc", "api-v2", "x"},
+			},
+			misses: [][]string{
+				{"svc", "api", "p99"},
+				{"svc", "ap", "latency"},
+			},
+		},
+		{
+			pattern: "a..b",
+			matches: [][]string{
+				{"a", "", "b"},
+			},
+			misses: [][]string{
+				{"a", "b"},
+			},
+		},
+		{
+			pattern: "a**b.c",
+			matches: [][]string{
+				{"ab", "c"},
+				{"axxb", "c"},
+			},
+			misses: [][]string{
+				{"a", "b", "c"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			require.NoError(b.SetSeparator('.'))
+			b.MustAddPattern("p", test.pattern)
+			mg := b.MustCompile()
+
+			for _, segs := range test.matches {
+				require.True(mg.MatchSegments(segs), "%q", segs)
+			}
+			for _, segs := range test.misses {
+				require.False(mg.MatchSegments(segs), "%q", segs)
+			}
+		})
+	}
+}
+
+func TestFindAllPatternsSegments(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	require.NoError(b.SetSeparator('/'))
+	b.MustAddPattern("all", "**")
+	b.MustAddPattern("api", "svc/api/*")
+	b.MustAddPattern("svc", "svc/**")
+	b.MustAddPattern("web", "svc/web/*")
+	mg := b.MustCompile()
+
+	found := mg.FindAllPatternsSegments([]string{"svc", "api", "latency"})
+	sort.Strings(found)
+	require.Equal([]string{"all", "api", "svc"}, found)
+
+	// Updates and encoding keep the segments
+	require.NoError(mg.RemovePattern("all"))
+	require.NoError(mg.ReplacePattern("web", "svc/*/latency"))
+
+	data, err := mg.MarshalBinary()
+	require.NoError(err)
+	var decoded MultiGlob
+	require.NoError(decoded.UnmarshalBinary(data))
+
+	for _, m := range []*MultiGlob{mg, &decoded} {
+		found = m.FindAllPatternsSegments([]string{"svc", "api", "latency"})
+		sort.Strings(found)
+		require.Equal([]string{"api", "svc", "web"}, found)
+	}
+}
+
+func TestMatchSegmentsWithoutSeparator(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("p", "a.*")
+	mg := b.MustCompile()
+
+	require.True(mg.MatchSegments([]string{"a.b"}))
+	require.False(mg.MatchSegments([]string{"a", "b"}))
+}
+
+func TestSetSeparatorErrors(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	for _, sep := range []rune{0, '*', '[', '\\', '+', -1} {
+		require.Error(b.SetSeparator(sep), "%q", sep)
+	}
+	require.NoError(b.SetSeparator('/'))
+}
@@ -0,0 +1,59 @@
+package multiglob
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// Span is the part of an input that one part of a pattern matched, as the byte offsets
+// [Start, End). Like input[Start:End], an empty span still has a position.
+type Span struct {
+	Start, End int
+	Literal    bool // Whether the part of the pattern is text, rather than a * or a range
+}
+
+// FindGlobSpans is like FindGlobs, but returns where each part of the pattern matched the
+// input instead of the globs. There's a span for each text, * and range of the pattern, in
+// order, so the spans that aren't Literal are the globs.
+func (mg *MultiGlob) FindGlobSpans(input string) (name string, spans []Span, matched bool) {
+	return mg.FindGlobSpansWith(input, CaptureGreedy)
+}
+
+// FindAllGlobSpans is like FindAllGlobs, but returns spans like FindGlobSpans.
+func (mg *MultiGlob) FindAllGlobSpans(input string) map[string][]Span {
+	patternNames := mg.FindAllPatterns(input)
+
+	spans := make(map[string][]Span)
+	for _, name := range patternNames {
+		s, _, _ := extractSpansForName(input, mg.patterns[name], CaptureGreedy)
+		spans[name] = s
+	}
+
+	return spans
+}
+
+// FindGlobSpansForPattern is like FindGlobsForPattern, but returns spans like FindGlobSpans.
+func (mg *MultiGlob) FindGlobSpansForPattern(input, name string) ([]Span, error) {
+	asts, ok := mg.patterns[name]
+	if !ok {
+		return nil, errors.New("pattern not found")
+	}
+
+	spans, _, err := extractSpansForName(input, asts, CaptureGreedy)
+	if err != nil {
+		return nil, errors.New("pattern did not match input")
+	}
+	return spans, nil
+}
+
+// extractSpansForName extracts the spans using the first of the patterns stored under a
+// name that matches the input, and returns its index.
+func extractSpansForName(input string, asts []*parser.Node, strategy CaptureStrategy) ([]Span, int, error) {
+	for i, ast := range asts {
+		if spans, err := extractSpans(input, ast, strategy); err == nil {
+			return spans, i, nil
+		}
+	}
+	return nil, 0, errTextNotFound
+}
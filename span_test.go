@@ -0,0 +1,109 @@
+package multiglob
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestFindGlobSpans(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		output  []Span
+	}{
+		{
+			input:   "test",
+			pattern: "test",
+			output: []Span{
+				{Start: 0, End: 4, Literal: true},
+			},
+		},
+		{
+			input:   "pen pineapple apple pen",
+			pattern: "*apple*",
+			output: []Span{
+				{Start: 0, End: 14},
+				{Start: 14, End: 19, Literal: true},
+				{Start: 19, End: 23},
+			},
+		},
+		{
+			input:   "foobar",
+			pattern: "*f*b*",
+			output: []Span{
+				{Start: 0, End: 0},
+				{Start: 0, End: 1, Literal: true},
+				{Start: 1, End: 3},
+				{Start: 3, End: 4, Literal: true},
+				{Start: 4, End: 6},
+			},
+		},
+		{
+			input:   "abab",
+			pattern: "*ab",
+			output: []Span{
+				{Start: 0, End: 2},
+				{Start: 2, End: 4, Literal: true},
+			},
+		},
+		{
+			input:   "xéèy",
+			pattern: "x[éè]+y",
+			output: []Span{
+				{Start: 0, End: 1, Literal: true},
+				{Start: 1, End: 5},
+				{Start: 5, End: 6, Literal: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			b.MustAddPattern("p", test.pattern)
+			mg := b.MustCompile()
+
+			name, spans, matched := mg.FindGlobSpans(test.input)
+			require.True(matched)
+			require.Equal("p", name)
+			require.Equal(test.output, spans)
+
+			_, globs, matched := mg.FindGlobs(test.input)
+			require.True(matched)
+			var fromSpans []string
+			for _, s := range spans {
+				if !s.Literal {
+					fromSpans = append(fromSpans, test.input[s.Start:s.End])
+				}
+			}
+			require.Equal(globs, fromSpans)
+
+			require.Equal(map[string][]Span{"p": test.output}, mg.FindAllGlobSpans(test.input))
+
+			spans, err := mg.FindGlobSpansForPattern(test.input, "p")
+			require.NoError(err)
+			require.Equal(test.output, spans)
+		})
+	}
+}
+
+func TestFindGlobSpansNoMatch(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("p", "*apple")
+	mg := b.MustCompile()
+
+	_, spans, matched := mg.FindGlobSpans("pear")
+	require.False(matched)
+	require.Nil(spans)
+	require.Empty(mg.FindAllGlobSpans("pear"))
+
+	_, err := mg.FindGlobSpansForPattern("pear", "p")
+	require.Error(err)
+	_, err = mg.FindGlobSpansForPattern("apple", "missing")
+	require.Error(err)
+}
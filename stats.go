@@ -0,0 +1,139 @@
+package multiglob
+
+import (
+	"sort"
+	"unsafe"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// Stats describes the shape and size of the merged tree of a MultiGlob.
+type Stats struct {
+	Nodes       int            // Number of nodes in the tree, including the root
+	NodesByType map[string]int // Number of nodes of each type, keyed like TreeNode.Type
+	MaxDepth    int            // Number of nodes on the longest path below the root
+	MaxFanOut   int            // Largest number of children of a node
+
+	// UnmergedRanges counts range nodes that have an identical sibling. Ranges are never
+	// merged, so patterns that share a range don't share the rest of their tree either.
+	UnmergedRanges int
+
+	// Bytes is an estimate of the memory held by the MultiGlob, including the trees kept for
+	// each pattern.
+	Bytes int64
+}
+
+// WideNode is a node of the merged tree with many children. See MultiGlob.WidestNodes.
+type WideNode struct {
+	Path     string // The pattern that leads from the root to the node, in canonical form
+	Children int
+}
+
+// Stats returns statistics about the merged tree of the MultiGlob. Watching them for
+// changes shows when a set of patterns makes the tree degenerate.
+func (mg *MultiGlob) Stats() Stats {
+	s := Stats{
+		NodesByType: make(map[string]int),
+	}
+	s.walk(mg.node, 0)
+
+	// The merged tree shares most of its nodes with the trees of the patterns, so each node is
+	// only counted the first time it's seen
+	var (
+		names = make(map[string]bool)
+		seen  = make(map[*parser.Node]bool)
+	)
+	s.Bytes = nodeBytes(mg.node, names, seen)
+	for name, asts := range mg.patterns {
+		// The key of the map, and the slice of trees
+		s.Bytes += int64(unsafe.Sizeof(name)+unsafe.Sizeof(asts)) + int64(cap(asts))*ptrSize
+		for _, p := range asts {
+			s.Bytes += nodeBytes(p, names, seen)
+		}
+	}
+	for name := range names {
+		s.Bytes += int64(len(name))
+	}
+
+	return s
+}
+
+func (s *Stats) walk(n *parser.Node, depth int) {
+	s.Nodes++
+	s.NodesByType[nodeTypeName(n.Type)]++
+	s.MaxDepth = max(s.MaxDepth, depth)
+	s.MaxFanOut = max(s.MaxFanOut, len(n.Children))
+
+	ranges := make(map[string]bool)
+	for _, child := range n.Children {
+		if child.Type == parser.TypeRange {
+			key := child.Range.String()
+			if ranges[key] {
+				s.UnmergedRanges++
+			}
+			ranges[key] = true
+		}
+
+		s.walk(child, depth+1)
+	}
+}
+
+const ptrSize = int64(unsafe.Sizeof(uintptr(0)))
+
+// nodeBytes estimates the memory held by the tree rooted at n, leaving out the nodes in seen
+// and adding the others to it. Pattern names are added to names instead, since they're shared
+// between trees.
+func nodeBytes(n *parser.Node, names map[string]bool, seen map[*parser.Node]bool) int64 {
+	if seen[n] {
+		return 0
+	}
+	seen[n] = true
+
+	size := int64(unsafe.Sizeof(*n)) + int64(len(n.Value)) + int64(cap(n.Children))*ptrSize
+	size += int64(cap(n.Name)) * int64(unsafe.Sizeof(""))
+	for _, name := range n.Name {
+		names[name] = true
+	}
+
+	if n.Range != nil {
+		size += int64(unsafe.Sizeof(*n.Range)) + int64(len(n.Range.CharList))
+		size += int64(cap(n.Range.Bounds)) * (ptrSize + int64(unsafe.Sizeof(parser.Bounds{})))
+	}
+
+	for _, child := range n.Children {
+		size += nodeBytes(child, names, seen)
+	}
+	return size
+}
+
+// WidestNodes returns up to n nodes of the merged tree with the most children, widest first.
+// Nodes with the same number of children are sorted by path.
+func (mg *MultiGlob) WidestNodes(n int) []WideNode {
+	var nodes []WideNode
+	var walk func(node *parser.Node, path string)
+	walk = func(node *parser.Node, path string) {
+		path += node.Pattern()
+		if len(node.Children) > 0 {
+			nodes = append(nodes, WideNode{
+				Path:     path,
+				Children: len(node.Children),
+			})
+		}
+		for _, child := range node.Children {
+			walk(child, path)
+		}
+	}
+	walk(mg.node, "")
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Children != nodes[j].Children {
+			return nodes[i].Children > nodes[j].Children
+		}
+		return nodes[i].Path < nodes[j].Path
+	})
+
+	if len(nodes) > n {
+		nodes = nodes[:max(n, 0)]
+	}
+	return nodes
+}
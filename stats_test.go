@@ -0,0 +1,89 @@
+package multiglob
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+
+	r "github.com/stretchr/testify/require"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+func TestStats(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("a", "foo")
+	b.MustAddPattern("b", "foo*")
+	b.MustAddPattern("c", "foo*bar")
+	b.MustAddPattern("d", "[0-9]x")
+	b.MustAddPattern("e", "[0-9]y")
+	mg := b.MustCompile()
+
+	stats := mg.Stats()
+	require.Equal(8, stats.Nodes)
+	require.Equal(map[string]int{
+		"root":  1,
+		"text":  4,
+		"any":   1,
+		"range": 2,
+	}, stats.NodesByType)
+	require.Equal(3, stats.MaxDepth)
+	require.Equal(3, stats.MaxFanOut)
+	require.Equal(1, stats.UnmergedRanges)
+	require.Positive(stats.Bytes)
+
+	// More patterns hold more memory
+	b.MustAddPattern("f", "something else entirely")
+	require.Greater(b.MustCompile().Stats().Bytes, stats.Bytes)
+}
+
+func TestWidestNodes(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	for i := 0; i < 5; i++ {
+		b.MustAddPattern(fmt.Sprint("wide", i), fmt.Sprintf("wide*%d", i))
+	}
+	b.MustAddPattern("narrow1", "narrow*a")
+	b.MustAddPattern("narrow2", "narrow*b")
+	mg := b.MustCompile()
+
+	require.Equal([]WideNode{
+		{Path: "wide*", Children: 5},
+		{Path: "", Children: 2},
+	}, mg.WidestNodes(2))
+	require.Len(mg.WidestNodes(10), 5)
+	require.Empty(mg.WidestNodes(0))
+}
+
+func TestStatsCountsSharedNodesOnce(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("a", "foo*bar")
+	mg := b.MustCompile()
+
+	// Below the root, the merged tree of a single pattern is made of the nodes of the pattern,
+	// so the tree of the pattern only adds its root and the entry for its name
+	p := mg.patterns["a"][0]
+	require.Same(p.Children[0], mg.node.Children[0])
+
+	tree := nodeBytes(mg.node, make(map[string]bool), make(map[*parser.Node]bool))
+	root := int64(unsafe.Sizeof(*p)) + int64(cap(p.Children))*ptrSize
+	entry := int64(unsafe.Sizeof("")+unsafe.Sizeof([]*parser.Node{})) + ptrSize + int64(len("a"))
+	require.Equal(tree+root+entry, mg.Stats().Bytes)
+}
+
+func TestWidestNodesAnySegments(t *testing.T) {
+	require := r.New(t)
+
+	// Whichever pattern is merged first, the any node they share is written as *
+	for i := 0; i < 10; i++ {
+		b := New()
+		b.MustAddPattern("segments", "svc.**.x")
+		b.MustAddPattern("single", "svc.*.y")
+		require.Equal([]WideNode{{Path: "svc.*", Children: 2}}, b.MustCompile().WidestNodes(1))
+	}
+}
@@ -0,0 +1,33 @@
+package multiglob
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/automaton"
+)
+
+// Subsumes reports whether pattern a matches every input that pattern b matches. If it
+// doesn't, it also returns one of the shortest inputs that b matches and a doesn't. Syntax
+// errors are reported as in AddPattern, with the patterns named "a" and "b".
+func Subsumes(a, b string) (bool, string, error) {
+	pa, err := parse("a", a)
+	if err != nil {
+		return false, "", errors.Wrap(err, "failed to parse pattern a")
+	}
+
+	pb, err := parse("b", b)
+	if err != nil {
+		return false, "", errors.Wrap(err, "failed to parse pattern b")
+	}
+
+	counterexample, found := automaton.Counterexample(automaton.New(pa), automaton.New(pb))
+	return !found, counterexample, nil
+}
+
+// Subsumes reports whether mg matches every input that other matches, which is to say that
+// mg.Match returns true whenever other.Match does. If it doesn't, it also returns one of the
+// shortest inputs that other matches and mg doesn't.
+func (mg *MultiGlob) Subsumes(other *MultiGlob) (bool, string) {
+	counterexample, found := automaton.Counterexample(automaton.New(mg.node), automaton.New(other.node))
+	return !found, counterexample
+}
@@ -0,0 +1,71 @@
+package multiglob
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestSubsumes(t *testing.T) {
+	tests := []struct {
+		a, b           string
+		subsumes       bool
+		counterexample string
+	}{
+		{a: "*", b: "foo", subsumes: true},
+		{a: "foo/*", b: "foo/bar/*", subsumes: true},
+		{a: "foo/bar/*", b: "foo/*", subsumes: false, counterexample: "foo/"},
+		{a: "v[0-9]+", b: "v[1-3]", subsumes: true},
+		{a: "v[1-3]", b: "v[0-9]+", subsumes: false, counterexample: "v0"},
+		{a: "[^.]*", b: "a*", subsumes: true},
+		{a: "a*", b: "[^.]*", subsumes: false, counterexample: "b"},
+		{a: "*.log", b: "*.*", subsumes: false, counterexample: "."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+" "+test.b, func(t *testing.T) {
+			require := r.New(t)
+
+			subsumes, counterexample, err := Subsumes(test.a, test.b)
+			require.NoError(err)
+			require.Equal(test.subsumes, subsumes)
+			require.Equal(test.counterexample, counterexample)
+		})
+	}
+}
+
+func TestSubsumesErrors(t *testing.T) {
+	require := r.New(t)
+
+	_, _, err := Subsumes("[a", "a")
+	require.EqualError(err, "failed to parse pattern a: failed to parse [a: unclosed range missing ]")
+
+	_, _, err = Subsumes("a", `a\`)
+	var pe *ParseError
+	require.ErrorAs(err, &pe)
+	require.Equal("b", pe.Name)
+}
+
+func TestMultiGlobSubsumes(t *testing.T) {
+	require := r.New(t)
+
+	broad := New()
+	broad.MustAddPattern("logs", "logs/*")
+	broad.MustAddPattern("metrics", "metrics/[a-z]+")
+
+	narrow := New()
+	narrow.MustAddPattern("errors", "logs/*.err")
+	narrow.MustAddPattern("cpu", "metrics/cpu")
+
+	b, n := broad.MustCompile(), narrow.MustCompile()
+
+	subsumes, counterexample := b.Subsumes(n)
+	require.True(subsumes)
+	require.Empty(counterexample)
+
+	subsumes, counterexample = n.Subsumes(b)
+	require.False(subsumes)
+	require.Equal("logs/", counterexample)
+	require.True(b.Match(counterexample))
+	require.False(n.Match(counterexample))
+}
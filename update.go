@@ -0,0 +1,76 @@
+package multiglob
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// RemovePattern removes the named pattern from the MultiGlob, including every pattern kept
+// under that name by DuplicateKeepAll. Instead of recompiling every pattern, only the
+// branches of the merged tree that lead to the pattern are rebuilt. RemovePattern is not
+// safe to call while the MultiGlob is being used for matching.
+func (mg *MultiGlob) RemovePattern(name string) error {
+	if _, ok := mg.patterns[name]; !ok {
+		return errors.New("pattern not found")
+	}
+
+	mg.node = parser.Remove(mg.node, name)
+	if mg.segments != nil {
+		mg.segments = parser.Remove(mg.segments, name)
+	}
+	delete(mg.patterns, name)
+	delete(mg.templates, name)
+	return nil
+}
+
+// ReplacePattern parses pattern and stores it under name, replacing the patterns that
+// were previously stored under that name. If there were none, it's added. The template of the
+// name is kept, and it fails if the pattern doesn't have the globs the template needs. Like
+// RemovePattern, it updates the merged tree incrementally and isn't safe to call while the
+// MultiGlob is being used for matching.
+func (mg *MultiGlob) ReplacePattern(name, pattern string) error {
+	p, err := parse(name, pattern)
+	if err != nil {
+		return errors.Wrap(err, "failed to replace pattern")
+	}
+	if t, ok := mg.templates[name]; ok {
+		if err := t.check(name, p); err != nil {
+			return errors.Wrap(err, "failed to replace pattern")
+		}
+	}
+
+	node := mg.node
+	if _, ok := mg.patterns[name]; ok {
+		node = parser.Remove(node, name)
+	}
+
+	mg.node = parser.Merge(node, p)
+	if mg.segments != nil {
+		mg.segments = parser.Merge(parser.Remove(mg.segments, name), parser.Segment(p, mg.separator))
+	}
+	mg.patterns[name] = []*parser.Node{p}
+	return nil
+}
+
+// clone returns a copy of mg that can be updated without affecting mg. The merged trees are
+// shared, since RemovePattern and ReplacePattern never modify them in place.
+func (mg *MultiGlob) clone() *MultiGlob {
+	patterns := make(map[string][]*parser.Node, len(mg.patterns))
+	for k, v := range mg.patterns {
+		patterns[k] = v
+	}
+
+	templates := make(map[string]*template, len(mg.templates))
+	for k, v := range mg.templates {
+		templates[k] = v
+	}
+
+	return &MultiGlob{
+		node:      mg.node,
+		patterns:  patterns,
+		templates: templates,
+		separator: mg.separator,
+		segments:  mg.segments,
+	}
+}
@@ -0,0 +1,158 @@
+package multiglob
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+
+	"github.com/szabado/multiglob/internal/parser"
+)
+
+// normalize returns a copy of the tree with its children and names sorted, so trees that
+// were merged in a different order can be compared.
+func normalize(n *parser.Node) *parser.Node {
+	if n == nil {
+		return nil
+	}
+
+	c := *n
+	if n.Name != nil {
+		c.Name = append([]string(nil), n.Name...)
+		sort.Strings(c.Name)
+	}
+
+	if n.Children != nil {
+		c.Children = make([]*parser.Node, 0, len(n.Children))
+		for _, child := range n.Children {
+			c.Children = append(c.Children, normalize(child))
+		}
+		sort.Slice(c.Children, func(i, j int) bool {
+			return fmt.Sprintf("%#v", c.Children[i]) < fmt.Sprintf("%#v", c.Children[j])
+		})
+	}
+
+	return &c
+}
+
+func TestRemovePattern(t *testing.T) {
+	tests := []struct {
+		patterns map[string]string
+		remove   []string
+		inputs   []string
+	}{
+		{
+			patterns: map[string]string{
+				"a": "foo*",
+				"b": "foobar",
+				"c": "*bar",
+			},
+			remove: []string{"b"},
+			inputs: []string{"foobar", "foo", "bar", "baz"},
+		},
+		{
+			patterns: map[string]string{
+				"a": "foo*bar",
+				"b": "foo*baz",
+				"c": "foo",
+			},
+			remove: []string{"a", "c"},
+			inputs: []string{"foobar", "foobaz", "foo", "fooqux"},
+		},
+		{
+			patterns: map[string]string{
+				"a": "[abc]+x",
+				"b": "[abc]+y",
+				"c": "*",
+			},
+			remove: []string{"c", "a"},
+			inputs: []string{"aax", "bby", "ccz", ""},
+		},
+		{
+			patterns: map[string]string{
+				"a": "test",
+			},
+			remove: []string{"a"},
+			inputs: []string{"test", ""},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			require := r.New(t)
+
+			b := New()
+			for name, pattern := range test.patterns {
+				b.MustAddPattern(name, pattern)
+			}
+			mg := b.MustCompile()
+
+			fresh := New()
+			for name, pattern := range test.patterns {
+				if !slices.Contains(test.remove, name) {
+					fresh.MustAddPattern(name, pattern)
+				}
+			}
+			expected := fresh.MustCompile()
+
+			for _, name := range test.remove {
+				require.NoError(mg.RemovePattern(name))
+			}
+
+			require.Equal(normalize(expected.node), normalize(mg.node))
+			require.Equal(expected.patterns, mg.patterns)
+
+			for _, input := range test.inputs {
+				want := expected.FindAllPatterns(input)
+				got := mg.FindAllPatterns(input)
+				sort.Strings(want)
+				sort.Strings(got)
+				require.Equal(want, got, input)
+			}
+		})
+	}
+}
+
+func TestRemovePatternNotFound(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("a", "foo")
+	mg := b.MustCompile()
+
+	require.Error(mg.RemovePattern("b"))
+	require.True(mg.Match("foo"))
+}
+
+func TestReplacePattern(t *testing.T) {
+	require := r.New(t)
+
+	b := New()
+	b.MustAddPattern("a", "foo*")
+	b.MustAddPattern("b", "*bar")
+	mg := b.MustCompile()
+
+	require.NoError(mg.ReplacePattern("a", "baz*"))
+	require.NoError(mg.ReplacePattern("c", "qux"))
+	require.Error(mg.ReplacePattern("d", "[abc"))
+
+	fresh := New()
+	fresh.MustAddPattern("a", "baz*")
+	fresh.MustAddPattern("b", "*bar")
+	fresh.MustAddPattern("c", "qux")
+	expected := fresh.MustCompile()
+
+	require.Equal(normalize(expected.node), normalize(mg.node))
+	require.Equal(expected.patterns, mg.patterns)
+
+	require.False(mg.Match("foo"))
+	require.True(mg.Match("bazooka"))
+	require.True(mg.Match("qux"))
+	require.True(mg.Match("foobar"))
+
+	globs, err := mg.FindGlobsForPattern("bazooka", "a")
+	require.NoError(err)
+	require.Equal([]string{"ooka"}, globs)
+}